@@ -2,6 +2,21 @@ package stubs
 
 var (
 	generatorAliases map[string]string
+
+	// generatorNames are the names of all known generators, except composite generators.
+	// It must be kept in sync with makeGenerators.
+	generatorNames = []string{
+		"adjective", "age", "amount", "base64", "birthdate", "bool", "bsonobjectid", "characters", "cidr", "city",
+		"city-prefix", "city-suffix", "company", "company-bs", "company-slogan", "company-suffix", "country",
+		"country-code", "credit-card", "currency-code", "date", "datetime", "domain", "domain-suffix", "double",
+		"duration", "email", "first-name", "float", "free-email", "hexcolor", "hostname", "int32", "int64", "ip",
+		"ipv4", "ipv6", "isbn", "isbn10", "isbn13", "iso8601-duration", "job-title", "landline", "last-name",
+		"latitude", "longitude", "mac-address", "mobile", "name", "name-prefix", "name-suffix", "noun", "number",
+		"paragraph", "paragraphs", "password", "pattern", "postcode", "recent-datetime", "rgbcolor", "safe-email",
+		"secondary-address", "sentence", "sentences", "silly-name", "small-amount", "ssn", "state", "state-name",
+		"street-address", "street-name", "street-suffix", "uint32", "uint64", "ulid", "uri", "user-name", "uuid",
+		"uuid3", "uuid4", "uuid5", "uuid7", "word", "words",
+	}
 )
 
 func init() {
@@ -13,6 +28,7 @@ func init() {
 	RegisterAltGenNames("country", "country-name")
 	RegisterAltGenNames("credit-card", "creditcard")
 	RegisterAltGenNames("domain", "domain-name")
+	RegisterAltGenNames("email", "email-address", "e-mail", "mail-address")
 	RegisterAltGenNames("hexcolor", "hex-color", "hexcolour", "hex-colour")
 	RegisterAltGenNames("hostname", "domainword", "domain-word", "host", "host-name")
 	RegisterAltGenNames("ipv4", "ip4", "ip", "ip-address")
@@ -23,7 +39,7 @@ func init() {
 	RegisterAltGenNames("latitude", "lat")
 	RegisterAltGenNames("longitude", "lon")
 	RegisterAltGenNames("mac-address", "mac", "macaddress")
-	RegisterAltGenNames("mobile", "mobile-number", "cell", "cell-phone", "gsm", "gsm-number")
+	RegisterAltGenNames("mobile", "mobile-number", "cell", "cell-phone", "mobile-phone", "gsm", "gsm-number")
 	RegisterAltGenNames("postcode", "zipcode", "post-code", "zip-code", "zip")
 	RegisterAltGenNames("rgbcolor", "rgb-color", "rgbcolour", "rgb-colour")
 	RegisterAltGenNames("sentences", "text", "phrases")
@@ -37,11 +53,13 @@ func init() {
 	RegisterAltGenNames("double", "float64")
 	RegisterAltGenNames("datetime", "date-time")
 	RegisterAltGenNames("amount", "price", "currency-amount", "cost", "turnover", "vat")
+	RegisterAltGenNames("birthdate", "birthday", "birth-date", "date-of-birth", "dob")
+	RegisterAltGenNames("recent-datetime", "timestamp", "created-at", "updated-at", "modified-at", "update-date", "last-update")
+	RegisterAltGenNames("age", "years-old")
 	RegisterAltGenNames("small-amount", "small-price", "low-price", "small-currency-amount", "low-cost", "fees")
 
 	// Now add keys for all known generators
-	g, _ := newGenerator("")
-	for key := range g.gens {
+	for _, key := range generatorNames {
 		if generatorAliases[key] == "" {
 			RegisterAltGenNames(key, key)
 		}
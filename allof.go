@@ -0,0 +1,351 @@
+package stubs
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/swag"
+)
+
+// schemaMerger flattens the members of an allOf composition into a single schema.
+//
+// Validations are intersected, so a value generated for the merged schema is valid against every member.
+type schemaMerger struct {
+	ctx      *schemaContext
+	merged   *spec.Schema
+	patterns []string // additional patterns, which could not be merged into the schema pattern
+}
+
+// mergeAllOf flattens a schema with allOf into a single schema, without allOf.
+//
+// It returns the merged schema, the context after following all $ref in members, and the extra patterns
+// which generated values must match.
+func (c *schemaContext) mergeAllOf(key string, schema *spec.Schema) (*spec.Schema, *schemaContext, []string, error) {
+	m := &schemaMerger{
+		ctx:    c,
+		merged: &spec.Schema{},
+	}
+	if err := m.merge(schema); err != nil {
+		return nil, nil, nil, fmt.Errorf("allOf members for [%s] are contradictory: %v", key, err)
+	}
+	return m.merged, m.ctx, m.patterns, nil
+}
+
+// merge merges a schema and its allOf members, recursively.
+//
+// The $ref followed to resolve a member are only accounted for in its nested members, not in its siblings.
+func (m *schemaMerger) merge(schema *spec.Schema) error {
+	resolved, ctx, err := m.ctx.resolve(schema)
+	if err != nil {
+		return err
+	}
+	m.ctx = ctx
+	if err := m.mergeOne(resolved); err != nil {
+		return err
+	}
+	for i := range resolved.AllOf {
+		err := m.merge(&resolved.AllOf[i])
+		m.ctx = ctx
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeOne merges the properties of a single schema, ignoring allOf
+func (m *schemaMerger) mergeOne(schema *spec.Schema) error {
+	target := m.merged
+
+	types, ok := mergeTypes(target.Type, schema.Type)
+	if !ok {
+		return fmt.Errorf("incompatible types %v and %v", target.Type, schema.Type)
+	}
+	target.Type = types
+
+	switch {
+	case target.Format == "":
+		target.Format = schema.Format
+	case schema.Format != "" && schema.Format != target.Format:
+		return fmt.Errorf("incompatible formats %q and %q", target.Format, schema.Format)
+	}
+
+	if target.Title == "" {
+		target.Title = schema.Title
+	}
+	if target.Description == "" {
+		target.Description = schema.Description
+	}
+	if target.Default == nil {
+		target.Default = schema.Default
+	}
+	if target.Example == nil {
+		target.Example = schema.Example
+	}
+	if target.Discriminator == "" {
+		target.Discriminator = schema.Discriminator
+	}
+	target.ReadOnly = target.ReadOnly || schema.ReadOnly
+	for k, v := range schema.Extensions {
+		if _, found := target.Extensions[k]; !found {
+			target.AddExtension(k, v)
+		}
+	}
+
+	if err := m.mergeNumbers(schema); err != nil {
+		return err
+	}
+	if err := m.mergeLengths(schema); err != nil {
+		return err
+	}
+
+	switch {
+	case schema.Pattern == "" || schema.Pattern == target.Pattern:
+	case target.Pattern == "":
+		target.Pattern = schema.Pattern
+	default:
+		m.patterns = append(m.patterns, schema.Pattern)
+	}
+
+	if len(schema.Enum) > 0 {
+		if len(target.Enum) == 0 {
+			target.Enum = schema.Enum
+		} else {
+			enum := make([]interface{}, 0, len(target.Enum))
+			for _, v := range target.Enum {
+				if containsValue(schema.Enum, v) {
+					enum = append(enum, v)
+				}
+			}
+			if len(enum) == 0 {
+				return fmt.Errorf("enums %v and %v have no common value", target.Enum, schema.Enum)
+			}
+			target.Enum = enum
+		}
+	}
+
+	for _, name := range schema.Required {
+		if !swag.ContainsStrings(target.Required, name) {
+			target.Required = append(target.Required, name)
+		}
+	}
+
+	if len(schema.Properties) > 0 {
+		properties := make(map[string]spec.Schema, len(target.Properties)+len(schema.Properties))
+		for name, prop := range target.Properties {
+			properties[name] = prop
+		}
+		for name, prop := range schema.Properties {
+			if existing, found := properties[name]; found {
+				// the same property is defined by several members: it must abide by both definitions
+				properties[name] = *spec.ComposedSchema(existing, prop)
+				continue
+			}
+			properties[name] = prop
+		}
+		target.Properties = properties
+	}
+
+	switch {
+	case schema.AdditionalProperties == nil:
+	case target.AdditionalProperties == nil || !schema.AdditionalProperties.Allows:
+		target.AdditionalProperties = schema.AdditionalProperties
+	case target.AdditionalProperties.Allows && schema.AdditionalProperties.Schema != nil:
+		if target.AdditionalProperties.Schema == nil {
+			target.AdditionalProperties = schema.AdditionalProperties
+		} else {
+			target.AdditionalProperties = &spec.SchemaOrBool{
+				Allows: true,
+				Schema: spec.ComposedSchema(*target.AdditionalProperties.Schema, *schema.AdditionalProperties.Schema),
+			}
+		}
+	}
+
+	if schema.Items != nil {
+		switch {
+		case target.Items == nil:
+			target.Items = schema.Items
+		case target.Items.Schema != nil && schema.Items.Schema != nil:
+			target.Items = &spec.SchemaOrArray{
+				Schema: spec.ComposedSchema(*target.Items.Schema, *schema.Items.Schema),
+			}
+		default:
+			return fmt.Errorf("tuples of items cannot be merged")
+		}
+	}
+	if target.AdditionalItems == nil {
+		target.AdditionalItems = schema.AdditionalItems
+	}
+	return nil
+}
+
+// mergeNumbers intersects numeric validations
+func (m *schemaMerger) mergeNumbers(schema *spec.Schema) error {
+	target := m.merged
+
+	if schema.Minimum != nil {
+		switch {
+		case target.Minimum == nil || *schema.Minimum > *target.Minimum:
+			target.Minimum = schema.Minimum
+			target.ExclusiveMinimum = schema.ExclusiveMinimum
+		case *schema.Minimum == *target.Minimum:
+			target.ExclusiveMinimum = target.ExclusiveMinimum || schema.ExclusiveMinimum
+		}
+	}
+	if schema.Maximum != nil {
+		switch {
+		case target.Maximum == nil || *schema.Maximum < *target.Maximum:
+			target.Maximum = schema.Maximum
+			target.ExclusiveMaximum = schema.ExclusiveMaximum
+		case *schema.Maximum == *target.Maximum:
+			target.ExclusiveMaximum = target.ExclusiveMaximum || schema.ExclusiveMaximum
+		}
+	}
+	if target.Minimum != nil && target.Maximum != nil {
+		if *target.Minimum > *target.Maximum ||
+			(*target.Minimum == *target.Maximum && (target.ExclusiveMinimum || target.ExclusiveMaximum)) {
+			return fmt.Errorf("minimum %v is incompatible with maximum %v", *target.Minimum, *target.Maximum)
+		}
+	}
+
+	if schema.MultipleOf != nil {
+		if target.MultipleOf == nil {
+			target.MultipleOf = schema.MultipleOf
+		} else {
+			multipleOf, ok := mergeMultipleOf(*target.MultipleOf, *schema.MultipleOf)
+			if !ok {
+				return fmt.Errorf("multipleOf %v and %v cannot be combined", *target.MultipleOf, *schema.MultipleOf)
+			}
+			target.MultipleOf = &multipleOf
+		}
+	}
+	return nil
+}
+
+// mergeLengths intersects validations on lengths, numbers of items and numbers of properties
+func (m *schemaMerger) mergeLengths(schema *spec.Schema) error {
+	target := m.merged
+
+	target.MinLength = maxInt64Ptr(target.MinLength, schema.MinLength)
+	target.MaxLength = minInt64Ptr(target.MaxLength, schema.MaxLength)
+	if target.MinLength != nil && target.MaxLength != nil && *target.MinLength > *target.MaxLength {
+		return fmt.Errorf("minLength %d is incompatible with maxLength %d", *target.MinLength, *target.MaxLength)
+	}
+
+	target.MinItems = maxInt64Ptr(target.MinItems, schema.MinItems)
+	target.MaxItems = minInt64Ptr(target.MaxItems, schema.MaxItems)
+	if target.MinItems != nil && target.MaxItems != nil && *target.MinItems > *target.MaxItems {
+		return fmt.Errorf("minItems %d is incompatible with maxItems %d", *target.MinItems, *target.MaxItems)
+	}
+	target.UniqueItems = target.UniqueItems || schema.UniqueItems
+
+	target.MinProperties = maxInt64Ptr(target.MinProperties, schema.MinProperties)
+	target.MaxProperties = minInt64Ptr(target.MaxProperties, schema.MaxProperties)
+	if target.MinProperties != nil && target.MaxProperties != nil && *target.MinProperties > *target.MaxProperties {
+		return fmt.Errorf("minProperties %d is incompatible with maxProperties %d", *target.MinProperties, *target.MaxProperties)
+	}
+	return nil
+}
+
+// mergeTypes intersects the types allowed by two schemas. An empty type allows any type.
+func mergeTypes(a, b spec.StringOrArray) (spec.StringOrArray, bool) {
+	if len(a) == 0 {
+		return b, true
+	}
+	if len(b) == 0 {
+		return a, true
+	}
+	res := make(spec.StringOrArray, 0, len(a))
+	for _, t := range a {
+		switch {
+		case b.Contains(t):
+			res = append(res, t)
+		case t == "integer" && b.Contains("number"), t == "number" && b.Contains("integer"):
+			// integers are numbers
+			res = append(res, "integer")
+		}
+	}
+	return res, len(res) > 0
+}
+
+// maxMultipleOfDecimals is the largest number of decimals considered to find a common multiple of decimal numbers
+const maxMultipleOfDecimals = 9
+
+// mergeMultipleOf finds a common multiple for two multipleOf validations.
+//
+// Decimal numbers are scaled to integers by their decimal precision, e.g. 0.3 and 0.7 have 2.1 as least common multiple.
+func mergeMultipleOf(a, b float64) (float64, bool) {
+	switch {
+	case isMultipleOf(a, b):
+		return a, true
+	case isMultipleOf(b, a):
+		return b, true
+	}
+	decimals := decimalsOf(a)
+	if d := decimalsOf(b); d > decimals {
+		decimals = d
+	}
+	scale := math.Pow10(decimals)
+	x, y := math.Round(a*scale), math.Round(b*scale)
+	if x <= 0 || y <= 0 || x*y > 1<<53 || !isMultipleOf(x/scale, a) || !isMultipleOf(y/scale, b) {
+		// the precision of the numbers is too large to find a common multiple
+		return 0, false
+	}
+	p, q := int64(x), int64(y)
+	for q != 0 {
+		p, q = q, p%q
+	}
+	return x / float64(p) * y / scale, true
+}
+
+// decimalsOf yields the number of decimals of a number, up to maxMultipleOfDecimals
+func decimalsOf(a float64) int {
+	for decimals := 0; decimals < maxMultipleOfDecimals; decimals++ {
+		scaled := a * math.Pow10(decimals)
+		if math.Abs(scaled-math.Round(scaled)) < 1e-9*math.Max(1, math.Abs(scaled)) {
+			return decimals
+		}
+	}
+	return maxMultipleOfDecimals
+}
+
+// isMultipleOf tells if a is a multiple of b, with some tolerance on rounding errors
+func isMultipleOf(a, b float64) bool {
+	if b == 0 {
+		return false
+	}
+	r := a / b
+	return math.Abs(r-math.Round(r)) < 1e-9
+}
+
+func maxInt64Ptr(a, b *int64) *int64 {
+	if a == nil || (b != nil && *b > *a) {
+		return b
+	}
+	return a
+}
+
+func minInt64Ptr(a, b *int64) *int64 {
+	if a == nil || (b != nil && *b < *a) {
+		return b
+	}
+	return a
+}
+
+// matchesPatterns tells if a generated value matches all the additional patterns merged from allOf.
+//
+// Patterns only apply to strings.
+func matchesPatterns(value interface{}, patterns []*regexp.Regexp) bool {
+	str, ok := value.(string)
+	if !ok {
+		return true
+	}
+	for _, p := range patterns {
+		if !p.MatchString(str) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,223 @@
+package stubs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// validationCheck designates a validation to fail at some path in the generated value.
+//
+// Paths are relative to the root value, e.g. "/address/city", "/items" or "/0" for the first item of a tuple.
+// Segments are escaped as in JSON pointers, e.g. "/a~1b" for a property named "a/b".
+type validationCheck struct {
+	path string
+	mode StubMode
+}
+
+// exclusiveModes are invalid modes which cannot be combined on the same value
+var exclusiveModes = [][2]StubMode{
+	{InvalidMaximum, InvalidMinimum},
+	{InvalidMaxLength, InvalidMinLength},
+	{InvalidMaxItems, InvalidMinItems},
+}
+
+// GenValidationChecks generates invalid samples for a descriptor, so that every declared validation is checked.
+//
+// By default, one sample is generated for each validation, nested validations included: each sample fails exactly
+// one validation. With all set to true, every combination of failed validations is generated, starting with
+// a valid sample. Combinations which cannot be generated (e.g. failing both minimum and maximum) are skipped.
+//
+// Validations are explored breadth-first, so outermost validations come first.
+// The number of samples is limited by count, unless count is zero. Since there are 2^n combinations of n validations,
+// combinations are limited to StubsDefaultValidationChecks samples when count is zero.
+//
+// Every sample is labelled with the StubMode flags it is expected to violate.
+func (s *Generator) GenValidationChecks(key string, descriptor interface{}, all bool, count int) ([]Sample, error) {
+	generator, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer s.release(generator)
+	return s.validationCheckSamples(generator, key, descriptor, all, count)
+}
+
+// validationCheckSamples generates invalid samples so that every declared validation is checked
+func (s *Generator) validationCheckSamples(generator *generators, key string, descriptor interface{}, all bool, count int) ([]Sample, error) {
+	gopts, err := s.genOptsFor(generator, key, descriptor)
+	if err != nil || gopts == nil {
+		return nil, err
+	}
+	checks := generator.validationChecks(gopts)
+	debugLog("%d validations to check for %q", len(checks), key)
+	if all && count <= 0 {
+		count = StubsDefaultValidationChecks
+	}
+
+	samples := make([]Sample, 0, len(checks)+1)
+	var genErr error
+	generateCheck := func(indices []int) bool {
+		if count > 0 && len(samples) >= count {
+			return false
+		}
+		overrides := make(map[string]StubMode, len(indices))
+		var violations StubMode
+		for _, i := range indices {
+			overrides[checks[i].path] |= checks[i].mode
+			violations |= checks[i].mode
+		}
+		for _, mode := range overrides {
+			if !compatibleModes(mode) {
+				return true
+			}
+		}
+
+		value, err := s.genSample(generator, key, descriptor, gopts, overrides, nil, violations)
+		switch {
+		case errors.Is(err, ErrNoInvalid), errors.Is(err, errNotApplied):
+			debugLog("validation check %v skipped for %q", overrides, key)
+			return true
+		case err != nil:
+			genErr = err
+			return false
+		}
+		samples = append(samples, Sample{
+			Value:      value,
+			Valid:      violations == Valid,
+			Violations: violations,
+			Origin:     OriginValidationCheck,
+		})
+		return true
+	}
+
+	if all {
+		forEachCombination(len(checks), generateCheck)
+	} else {
+		for i := range checks {
+			if !generateCheck([]int{i}) {
+				break
+			}
+		}
+	}
+	if genErr != nil {
+		return nil, genErr
+	}
+	return samples, nil
+}
+
+// validationChecks lists the validations declared by some generation options and their nested options, breadth-first
+func (g *generators) validationChecks(opts GeneratorOpts) []validationCheck {
+	checks := make([]validationCheck, 0, 20)
+	walkOpts(opts, func(path, _ string, current GeneratorOpts) {
+		modes := validationModes(current)
+		for flag := InvalidRequired; flag <= InvalidEnum; flag <<= 1 {
+			if modes.Has(flag) {
+				checks = append(checks, validationCheck{path: path, mode: flag})
+			}
+		}
+	})
+	return checks
+}
+
+// walkOpts visits some generation options and their nested options, breadth-first.
+//
+// Paths of nested options are those used when generating values. The visitor is passed the path of the options,
+// and the path of their parent (the root has an empty path and parent path).
+// Nested options which cannot be built, e.g. when a recursion exceeds the max depth, are not explored.
+func walkOpts(opts GeneratorOpts, visit func(path, parent string, opts GeneratorOpts)) {
+	type node struct {
+		path   string
+		parent string
+		opts   GeneratorOpts
+	}
+	queue := []node{{opts: opts}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		visit(current.path, current.parent, current.opts)
+		child := func(segment string, opts GeneratorOpts) node {
+			return node{path: childPath(current.path, segment), parent: current.path, opts: opts}
+		}
+
+		switch current.opts.Type() {
+		case "object":
+			obj, ok := current.opts.(objectGeneratorOpts)
+			if !ok {
+				continue
+			}
+			props, err := obj.Properties()
+			if err != nil {
+				debugLog("options of %q not explored: %v", current.path, err)
+				continue
+			}
+			for _, prop := range props {
+				queue = append(queue, child(prop.FieldName(), prop))
+			}
+		case "array":
+			if t, ok := current.opts.(tupleGeneratorOpts); ok {
+				tuple, err := t.TupleItems()
+				if err != nil {
+					debugLog("options of %q not explored: %v", current.path, err)
+					continue
+				}
+				if tuple != nil {
+					for i, item := range tuple {
+						queue = append(queue, child(strconv.Itoa(i), item))
+					}
+					continue
+				}
+			}
+			items, err := current.opts.Items()
+			if err != nil {
+				debugLog("options of %q not explored: %v", current.path, err)
+				continue
+			}
+			queue = append(queue, child("items", items))
+		}
+	}
+}
+
+// pathEscaper escapes a path segment as in JSON pointers
+var pathEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// childPath yields the path of a value nested in the value at some path
+func childPath(path, segment string) string {
+	return path + "/" + pathEscaper.Replace(segment)
+}
+
+// compatibleModes tells if the invalid flags in a mode may be combined on a single value
+func compatibleModes(mode StubMode) bool {
+	for _, pair := range exclusiveModes {
+		if mode.Has(pair[0]) && mode.Has(pair[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// forEachCombination calls fn with all the subsets of indices in [0, n), by increasing size, until fn returns false
+func forEachCombination(n int, fn func([]int) bool) {
+	for k := 0; k <= n; k++ {
+		indices := make([]int, k)
+		for i := range indices {
+			indices[i] = i
+		}
+		for {
+			if !fn(indices) {
+				return
+			}
+			// next combination of size k, in lexicographic order
+			i := k - 1
+			for i >= 0 && indices[i] == n-k+i {
+				i--
+			}
+			if i < 0 {
+				break
+			}
+			indices[i]++
+			for j := i + 1; j < k; j++ {
+				indices[j] = indices[j-1] + 1
+			}
+		}
+	}
+}
@@ -0,0 +1,554 @@
+package stubs
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	regen "github.com/zach-klippenstein/goregen"
+)
+
+const (
+	// maxUniqueItemsRetries is the number of attempts made to produce a new unique member in an array
+	maxUniqueItemsRetries = 10
+	// maxPatternsRetries is the number of attempts made to produce a value matching several patterns
+	maxPatternsRetries = 100
+)
+
+// objectGeneratorOpts is implemented by generation options which describe an object with properties
+type objectGeneratorOpts interface {
+	GeneratorOpts
+
+	// Properties yields the generation options for all the properties of an object
+	Properties() ([]GeneratorOpts, error)
+
+	// AdditionalProperties yields the generation options for additional properties, or nil if these are not generated
+	AdditionalProperties() (GeneratorOpts, error)
+
+	// PatternProperties yields the patterns of property keys and the generation options for their values
+	PatternProperties() ([]string, []GeneratorOpts, error)
+
+	// MinProperties an object must have, returns value, defined
+	MinProperties() (int64, bool)
+
+	// MaxProperties an object can have, returns value, defined
+	MaxProperties() (int64, bool)
+}
+
+// tupleGeneratorOpts is implemented by generation options which may describe an array with positional items
+type tupleGeneratorOpts interface {
+	GeneratorOpts
+
+	// TupleItems yields the generation options for positional items, or nil if items are not a tuple
+	TupleItems() ([]GeneratorOpts, error)
+
+	// AdditionalItems yields the generation options for items beyond the tuple, or nil if these are not allowed
+	AdditionalItems() (GeneratorOpts, error)
+}
+
+// depthLimitedOpts is implemented by generation options which may stop generating nested values,
+// e.g. to break recursion in schemas
+type depthLimitedOpts interface {
+	// Exhausted tells if nested values should be kept to a minimum
+	Exhausted() bool
+}
+
+// polymorphicOpts is implemented by generation options which may describe a subtype picked through a discriminator
+type polymorphicOpts interface {
+	// Discriminator yields the discriminator property and the value identifying the picked subtype
+	Discriminator() (string, string, bool)
+}
+
+// multiPatternOpts is implemented by generation options which may require values to match several patterns,
+// e.g. when merging allOf members
+type multiPatternOpts interface {
+	// ExtraPatterns yields the patterns a value must match, besides the one returned by Pattern()
+	ExtraPatterns() []*regexp.Regexp
+}
+
+// isCompositeGenerator tells if a generator name refers to a composite generator,
+// or to a generator which only draws from validations, such as enum.
+//
+// Composite generators are not proposed for inference based on names or descriptions.
+func isCompositeGenerator(name string) bool {
+	switch name {
+	case "object", "array", "enum":
+		return true
+	default:
+		return false
+	}
+}
+
+// generate infers the value generator for some generation options and calls it.
+//
+// This is used by composite generators to produce their members.
+//
+// Invalid values are produced by tilting a valid value, unless the "skipTilting" argument is set or tilting fails:
+// the value generator then produces the invalid value by itself.
+// In Invalid mode, without more specific flags, the failed validation is chosen at random.
+// With the "withTilting" argument, a valid mode is tilted on a validation chosen at random.
+//
+// The mode of the options is restored once the value is generated, so the options may be used again
+// to generate other values.
+func (g *generators) generate(opts GeneratorOpts) (interface{}, error) {
+	opts.Infer()
+	datagen, found := g.For(opts)
+	if !found {
+		return nil, fmt.Errorf("no generator found for [%s]", opts.FieldName())
+	}
+	defer opts.SetMode(opts.Mode())
+
+	if injected, ok := g.injections[g.path]; ok {
+		g.visited[g.path] = true
+		return g.inject(injected, opts)
+	}
+	if override, ok := g.overrides[g.path]; ok {
+		g.visited[g.path] = true
+		opts.SetMode(override)
+	}
+	mode := opts.Mode()
+	args := opts.Args()
+	switch {
+	case mode == Invalid:
+		mode = g.pickMode(validationModes(opts))
+		if mode == Valid {
+			return nil, ErrNoInvalid
+		}
+		opts.SetMode(mode)
+	case mode == Valid && args.WithTilting.Enabled && !args.SkipTilting:
+		mode = g.pickMode(validationModes(opts) &^ InvalidRequired)
+		if mode == Valid {
+			return nil, ErrNoInvalid
+		}
+		opts.SetMode(mode)
+	}
+
+	var tilted StubMode
+	for _, flag := range tiltableModes {
+		tilted |= mode & flag
+	}
+	if tilted == Valid || args.SkipTilting {
+		return g.generatePatterns(datagen, opts)
+	}
+
+	opts.SetMode(mode &^ tilted)
+	value, err := g.generatePatterns(datagen, opts)
+	opts.SetMode(mode)
+	if err == nil {
+		if value, err = g.tilt(value, opts, tilted); err == nil {
+			return value, nil
+		}
+	}
+	debugLog("tilting failed for %q: %v", opts.FieldName(), err)
+	return g.generatePatterns(datagen, opts)
+}
+
+// generateChild generates a value nested in the value being generated, e.g. a property in an object
+func (g *generators) generateChild(segment string, opts GeneratorOpts) (interface{}, error) {
+	parent := g.path
+	g.path = childPath(parent, segment)
+	defer func() { g.path = parent }()
+	return g.generate(opts)
+}
+
+// isOverridden tells if the mode of a nested value, or of any value nested below it, is overridden,
+// or if such a value is injected
+func (g *generators) isOverridden(segment string) bool {
+	path := childPath(g.path, segment)
+	below := func(overridden string) bool {
+		return overridden == path || strings.HasPrefix(overridden, path+"/")
+	}
+	for overridden := range g.overrides {
+		if below(overridden) {
+			return true
+		}
+	}
+	for injected := range g.injections {
+		if below(injected) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatePatterns calls a value generator.
+//
+// When several patterns apply, values are generated again until all patterns are matched.
+func (g *generators) generatePatterns(datagen ValueGenerator, opts GeneratorOpts) (interface{}, error) {
+	m, ok := opts.(multiPatternOpts)
+	if !ok || len(m.ExtraPatterns()) == 0 {
+		return datagen(opts)
+	}
+	for attempts := 0; attempts < maxPatternsRetries; attempts++ {
+		value, err := datagen(opts)
+		if err != nil || matchesPatterns(value, m.ExtraPatterns()) {
+			return value, err
+		}
+	}
+	debugLog("could not generate a value matching all patterns for %q", opts.FieldName())
+	return nil, ErrNoValid
+}
+
+// genObject generates an object as a map of properties.
+//
+// Required properties are always generated. Optional properties are generated at random,
+// within the bounds set by minProperties and maxProperties.
+// Extra properties are added whenever additionalProperties or patternProperties allow for it.
+// In InvalidRequired mode, one of the required properties is omitted.
+func (g *generators) genObject(opts GeneratorOpts) (interface{}, error) {
+	obj, ok := opts.(objectGeneratorOpts)
+	if !ok {
+		return nil, fmt.Errorf("options for [%s] do not describe an object", opts.FieldName())
+	}
+	props, err := obj.Properties()
+	if err != nil {
+		return nil, err
+	}
+
+	skipped := ""
+	if opts.Mode().Has(InvalidRequired) {
+		required := make([]string, 0, len(props))
+		for _, prop := range props {
+			// properties with an overridden mode are not omitted
+			if prop.Required() && !g.isOverridden(prop.FieldName()) {
+				required = append(required, prop.FieldName())
+			}
+		}
+		if len(required) == 0 {
+			return nil, ErrNoInvalid
+		}
+		skipped = required[g.entropy.IntN(len(required))]
+	}
+
+	minProperties, definedMin := obj.MinProperties()
+	maxProperties, definedMax := obj.MaxProperties()
+
+	res := make(map[string]interface{}, len(props))
+	// required properties come first
+	for _, prop := range props {
+		name := prop.FieldName()
+		if !prop.Required() || name == skipped {
+			continue
+		}
+		debugLog("generating property %q of object %q", name, opts.FieldName())
+		value, err := g.generateChild(name, prop)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = value
+	}
+	if definedMax && int64(len(res)) > maxProperties {
+		debugLog("too many required properties for %q: max=%d", opts.FieldName(), maxProperties)
+		return nil, ErrNoValid
+	}
+
+	for _, prop := range props {
+		name := prop.FieldName()
+		if prop.Required() || (definedMax && int64(len(res)) >= maxProperties) {
+			continue
+		}
+		if !g.entropy.Bool() && (!definedMin || int64(len(res)) >= minProperties) && !g.isOverridden(name) {
+			continue
+		}
+		debugLog("generating property %q of object %q", name, opts.FieldName())
+		value, err := g.generateChild(name, prop)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = value
+	}
+
+	if err := g.genExtraProperties(obj, props, res); err != nil {
+		return nil, err
+	}
+
+	if p, ok := opts.(polymorphicOpts); ok {
+		if name, value, isSubtype := p.Discriminator(); isSubtype && name != skipped {
+			res[name] = value
+		}
+	}
+	return res, nil
+}
+
+// genExtraProperties adds a random number of properties to an object, with keys not declared as properties.
+//
+// Keys are synthesized from the regexps in patternProperties or picked at random for additionalProperties.
+// The number of extra properties is limited by the "length" argument.
+func (g *generators) genExtraProperties(obj objectGeneratorOpts, props []GeneratorOpts, res map[string]interface{}) error {
+	minProperties, definedMin := obj.MinProperties()
+	maxProperties, definedMax := obj.MaxProperties()
+
+	additional, err := obj.AdditionalProperties()
+	if err != nil {
+		return err
+	}
+	patterns, patternOpts, err := obj.PatternProperties()
+	if err != nil {
+		return err
+	}
+
+	lo, hi := 0, StubsDefaultPropertiesCount
+	if args := obj.Args(); args != nil && args.Length > 0 {
+		hi = args.Length
+	}
+	if definedMin && minProperties > int64(len(res)) {
+		lo = int(minProperties) - len(res)
+	}
+	if hi < lo {
+		hi = lo
+	}
+	if definedMax && int(maxProperties)-len(res) < hi {
+		hi = int(maxProperties) - len(res)
+	}
+	if d, ok := obj.(depthLimitedOpts); ok && d.Exhausted() && hi > lo {
+		hi = lo
+	}
+	if additional == nil && len(patterns) == 0 {
+		hi = 0
+	}
+	if hi < lo {
+		debugLog("cannot generate %d properties for %q", lo, obj.FieldName())
+		return ErrNoValid
+	}
+	count := lo + g.entropy.IntN(hi-lo+1)
+	if count == 0 {
+		return nil
+	}
+
+	declared := make(map[string]bool, len(props))
+	for _, prop := range props {
+		declared[prop.FieldName()] = true
+	}
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	translated := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		tr, err := translatePattern(pattern)
+		if err != nil {
+			return err
+		}
+		re, err := regexp.Compile(tr)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, re)
+		translated = append(translated, tr)
+	}
+
+	sources := len(patterns)
+	if additional != nil {
+		sources++
+	}
+	for added, attempts := 0, 0; added < count; attempts++ {
+		if attempts > count*maxUniqueItemsRetries {
+			debugLog("could not generate %d extra properties for %q", count, obj.FieldName())
+			return ErrNoValid
+		}
+
+		var key string
+		var valueOpts GeneratorOpts
+		source := g.entropy.IntN(sources)
+		if source < len(patterns) {
+			rexgen, err := regen.NewGenerator(translated[source], g.regenArgs)
+			if err != nil {
+				return err
+			}
+			key, valueOpts = rexgen.Generate(), patternOpts[source]
+		} else {
+			key, valueOpts = g.faker.Words(1, false)[0], additional
+			if _, exists := res[key]; exists || declared[key] {
+				// disambiguate words already used
+				key = fmt.Sprintf("%s%d", key, attempts)
+			}
+		}
+		if _, exists := res[key]; exists || declared[key] || key == "" {
+			continue
+		}
+		// a key must not match patterns from other sources, or its value would have to abide by several schemas
+		conflict := false
+		for i, re := range compiled {
+			if i != source && re.MatchString(key) {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			continue
+		}
+
+		debugLog("generating extra property %q of object %q", key, obj.FieldName())
+		value, err := g.generateChild(key, valueOpts)
+		if err != nil {
+			return err
+		}
+		res[key] = value
+		added++
+	}
+	return nil
+}
+
+// genArray generates an array of items.
+//
+// The number of items abides by the minItems and maxItems validations and is limited by the "length" argument.
+// When uniqueItems is specified, duplicate members are discarded and generated again.
+func (g *generators) genArray(opts GeneratorOpts) (interface{}, error) {
+	var tuple []GeneratorOpts
+	var additional GeneratorOpts
+	if t, ok := opts.(tupleGeneratorOpts); ok {
+		var err error
+		if tuple, err = t.TupleItems(); err != nil {
+			return nil, err
+		}
+		if tuple != nil {
+			if additional, err = t.AdditionalItems(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var items GeneratorOpts
+	if tuple == nil {
+		var err error
+		if items, err = opts.Items(); err != nil {
+			return nil, err
+		}
+	}
+
+	count, err := g.itemsCount(opts, tuple, additional != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// itemOpts selects the generation options for the item at some position, and the path segment for this item
+	itemOpts := func(i int) (string, GeneratorOpts) {
+		switch {
+		case tuple == nil:
+			return "items", items
+		case i < len(tuple):
+			return strconv.Itoa(i), tuple[i]
+		case additional != nil:
+			return "additionalItems", additional
+		default:
+			// only reached when generating invalid arrays, with more items than allowed
+			return strconv.Itoa(len(tuple) - 1), tuple[len(tuple)-1]
+		}
+	}
+
+	if count == 0 && tuple == nil && g.isOverridden("items") {
+		// at least one item is needed to carry the overridden mode
+		maxItems, definedMax := opts.MaxItems()
+		minItems, _ := opts.MinItems()
+		if (!definedMax || maxItems > 0) && (!opts.Mode().Has(InvalidMinItems) || minItems > 1) {
+			count = 1
+		}
+	}
+
+	mode := opts.Mode()
+	unique := opts.UniqueItems() && !mode.Has(InvalidUniqueItems)
+	if mode.Has(InvalidUniqueItems) {
+		// the last item is a duplicate
+		count--
+	}
+
+	res := make([]interface{}, 0, count+1)
+	for attempts := 0; len(res) < count; attempts++ {
+		if attempts > count*maxUniqueItemsRetries {
+			debugLog("could not generate %d unique items for %q", count, opts.FieldName())
+			return nil, ErrNoValid
+		}
+		value, err := g.generateChild(itemOpts(len(res)))
+		if err != nil {
+			return nil, err
+		}
+		if unique && containsValue(res, value) {
+			continue
+		}
+		res = append(res, value)
+	}
+
+	if mode.Has(InvalidUniqueItems) {
+		res = append(res, res[g.entropy.IntN(len(res))])
+	}
+	return res, nil
+}
+
+// itemsCount determines the number of items to generate in an array
+func (g *generators) itemsCount(opts GeneratorOpts, tuple []GeneratorOpts, hasAdditional bool) (int, error) {
+	minItems, definedMin := opts.MinItems()
+	maxItems, definedMax := opts.MaxItems()
+
+	lo, hi := 0, StubsDefaultItemsCount
+	if args := opts.Args(); args != nil && args.Length > 0 {
+		hi = args.Length
+	}
+	if definedMin && int(minItems) > lo {
+		lo = int(minItems)
+	}
+	if hi < lo {
+		hi = lo
+	}
+	if definedMax && int(maxItems) < hi {
+		hi = int(maxItems)
+	}
+	if d, ok := opts.(depthLimitedOpts); ok && d.Exhausted() && hi > lo {
+		debugLog("max depth reached: generating %d items for %q", lo, opts.FieldName())
+		hi = lo
+	}
+
+	if tuple != nil {
+		if !hasAdditional && hi > len(tuple) {
+			hi = len(tuple)
+		}
+		// generate the full tuple whenever possible
+		if lo < len(tuple) {
+			lo = len(tuple)
+			if hi < lo {
+				lo = hi
+			}
+		}
+	}
+
+	mode := opts.Mode()
+	switch {
+	case mode.Has(InvalidMinItems):
+		if !definedMin || minItems == 0 { // Safeguard
+			return 0, ErrNoInvalid
+		}
+		lo, hi = 0, int(minItems)-1
+	case mode.Has(InvalidMaxItems):
+		if !definedMax { // Safeguard
+			return 0, ErrNoInvalid
+		}
+		lo = int(maxItems) + 1
+		if hi < lo {
+			hi = lo
+		}
+	case mode.Has(InvalidUniqueItems):
+		if !opts.UniqueItems() || tuple != nil { // Safeguard
+			return 0, ErrNoInvalid
+		}
+		if lo < 2 {
+			lo = 2
+		}
+		if hi < lo {
+			return 0, ErrNoInvalid
+		}
+	}
+
+	if hi < lo {
+		debugLog("contradictory items count for %q: min=%d, max=%d", opts.FieldName(), lo, hi)
+		return 0, ErrNoValid
+	}
+	return lo + g.entropy.IntN(hi-lo+1), nil
+}
+
+// containsValue tells if a value is already present in a slice of values
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
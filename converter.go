@@ -6,6 +6,9 @@ import conv "github.com/cstockton/go-conv"
 type Converter interface {
 	Bool(interface{}) (bool, error)
 	Int(interface{}) (int, error)
+	Int64(interface{}) (int64, error)
+	Uint64(interface{}) (uint64, error)
+	Float64(interface{}) (float64, error)
 }
 
 type defaultConverter struct {
@@ -18,3 +21,15 @@ func (d defaultConverter) Bool(from interface{}) (bool, error) {
 func (d defaultConverter) Int(from interface{}) (int, error) {
 	return conv.Int(from)
 }
+
+func (d defaultConverter) Int64(from interface{}) (int64, error) {
+	return conv.Int64(from)
+}
+
+func (d defaultConverter) Uint64(from interface{}) (uint64, error) {
+	return conv.Uint64(from)
+}
+
+func (d defaultConverter) Float64(from interface{}) (float64, error) {
+	return conv.Float64(from)
+}
@@ -0,0 +1,161 @@
+package stubs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/swag"
+)
+
+const (
+	// definitionsPrefix is the prefix of $ref to definitions in a swagger spec
+	definitionsPrefix = "#/definitions/"
+	// xDiscriminatorValue is the extension which overrides the discriminator value of a subtype
+	xDiscriminatorValue = "x-discriminator-value"
+)
+
+// definitionName yields the name of the definition reached by the last $ref followed,
+// or an empty string when this $ref does not point to a definition
+func (c *schemaContext) definitionName() string {
+	if c == nil || len(c.refs) == 0 {
+		return ""
+	}
+	ref := c.refs[len(c.refs)-1]
+	if !strings.HasPrefix(ref, definitionsPrefix) {
+		return ""
+	}
+	name := strings.TrimPrefix(ref, definitionsPrefix)
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+}
+
+// hasDefinition tells if the root document holds a definition with this name
+func (c *schemaContext) hasDefinition(name string) bool {
+	if c == nil || c.root == nil || name == "" {
+		return false
+	}
+	_, found := c.root.Definitions[name]
+	return found
+}
+
+// subtypesOf finds all the definitions which extend a base definition through allOf, directly or not.
+//
+// Subtypes are sorted by name.
+func (c *schemaContext) subtypesOf(base string) []string {
+	if c == nil || c.root == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.root.Definitions))
+	for name := range c.root.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parents := map[string]bool{base: true}
+	subtypes := make([]string, 0, 10)
+	for found := true; found; {
+		found = false
+		for _, name := range names {
+			if parents[name] {
+				continue
+			}
+			def := c.root.Definitions[name]
+			for _, member := range def.AllOf {
+				ref := member.Ref.String()
+				if strings.HasPrefix(ref, definitionsPrefix) && parents[strings.TrimPrefix(ref, definitionsPrefix)] {
+					parents[name] = true
+					subtypes = append(subtypes, name)
+					found = true
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(subtypes)
+	return subtypes
+}
+
+// pickSubtype selects at random one of the subtypes of a base definition with a discriminator.
+//
+// The choice may be pinned or weighted with the "subtype" and "subtypes" arguments of the x-datagen extension on the base.
+// It returns the schema of the subtype, the context after following the subtype's definition and the discriminator value
+// for this subtype.
+//
+// The subtype is followed from the context which led to the base (baseCtx is the context after following the base),
+// so the base referenced by the subtype in allOf is not considered a recursion.
+func (c *schemaContext) pickSubtype(base string, schema *spec.Schema, baseCtx *schemaContext) (*spec.Schema, *schemaContext, string, error) {
+	var baseOpts genOpts
+	if err := baseOpts.ExtOverride(schema.Extensions); err != nil {
+		return nil, nil, "", err
+	}
+	args := baseOpts.Args()
+
+	subtypes := baseCtx.subtypesOf(base)
+	candidates := make([]string, 0, len(subtypes))
+	weights := make([]float64, 0, len(subtypes))
+	switch {
+	case args.Subtype != "":
+		if args.Subtype != base && !swag.ContainsStrings(subtypes, args.Subtype) {
+			return nil, nil, "", fmt.Errorf("%q is not a subtype of %q", args.Subtype, base)
+		}
+		candidates = append(candidates, args.Subtype)
+		weights = append(weights, 1)
+	case len(args.Subtypes) > 0:
+		for _, name := range append([]string{base}, subtypes...) {
+			if w := args.Subtypes[name]; w > 0 {
+				candidates = append(candidates, name)
+				weights = append(weights, w)
+			}
+		}
+	default:
+		for _, name := range subtypes {
+			candidates = append(candidates, name)
+			weights = append(weights, 1)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if len(args.Subtypes) > 0 {
+			return nil, nil, "", fmt.Errorf("no subtype of %q is eligible with weights %v", base, args.Subtypes)
+		}
+		// no subtype: the base is generated as is
+		debugLog("no subtype found for %q", base)
+		return schema, baseCtx, discriminatorValueOf(base, schema), nil
+	}
+
+	index, err := baseCtx.pickWeighted(weights)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	picked := candidates[index]
+	debugLog("subtype %q picked for discriminated type %q", picked, base)
+	if picked == base {
+		return schema, baseCtx, discriminatorValueOf(base, schema), nil
+	}
+
+	ctx, err := c.follow(definitionsPrefix + picked)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	subtype := baseCtx.root.Definitions[picked]
+	return &subtype, ctx, discriminatorValueOf(picked, &subtype), nil
+}
+
+// discriminatorValueOf yields the discriminator value for a definition.
+//
+// The value defaults to the name of the definition and may be overridden by the x-discriminator-value extension.
+func discriminatorValueOf(name string, schema *spec.Schema) string {
+	if v, ok := schema.Extensions[xDiscriminatorValue].(string); ok && v != "" {
+		return v
+	}
+	return name
+}
+
+// pickWeighted selects at random an index in a slice of weights
+func (c *schemaContext) pickWeighted(weights []float64) (int, error) {
+	if c == nil || c.entropy == nil {
+		return 0, nil
+	}
+	return c.entropy.Weighted(weights)
+}
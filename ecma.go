@@ -0,0 +1,488 @@
+package stubs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ecmaGroup is a capture group of an ECMA-262 pattern, with its translated source once closed
+type ecmaGroup struct {
+	name   string
+	source string
+	closed bool
+}
+
+// ecmaTranslator translates an ECMA-262 regular expression into the syntax of Go regexp
+type ecmaTranslator struct {
+	pattern  string
+	src      []rune
+	pos      int
+	out      strings.Builder
+	groups   []*ecmaGroup
+	captures int // number of capture groups in the whole pattern
+
+	approximated bool // some constructs are approximated in the translated pattern
+}
+
+// ecmaOpen is a parenthesis opened in the translated pattern
+type ecmaOpen struct {
+	start      int        // offset of the group in the translated pattern
+	group      *ecmaGroup // the capture group, if any
+	lookaround bool       // lookarounds are dropped from the translated pattern
+}
+
+// translatePattern translates a pattern from the ECMA-262 dialect used by JSON schema into the syntax of Go regexp.
+//
+// Constructs unknown to Go regexp are converted whenever possible:
+//   - \uXXXX, \u{X...}, \cX, \0 and legacy octal escapes are converted to \x{...}
+//   - named groups (?<name>...) are converted to (?P<name>...)
+//   - [^] is converted to a class of all characters, and \b in a class to a backspace
+//   - identity escapes of letters, such as \a or \e, stand for the letter
+//
+// Other constructs are approximated, so values generated from the translated pattern may not match the original:
+//   - lookaheads and lookbehinds are dropped
+//   - backreferences, \1 or \k<name>, are replaced by a copy of the referenced group
+//
+// It returns an error for constructs which can be neither converted nor approximated.
+func translatePattern(pattern string) (string, error) {
+	translated, _, err := translateExactPattern(pattern)
+	return translated, err
+}
+
+// translateExactPattern translates a pattern like translatePattern, and tells if the translation is exact,
+// i.e. if no construct is approximated
+func translateExactPattern(pattern string) (string, bool, error) {
+	t := &ecmaTranslator{pattern: pattern, src: []rune(pattern)}
+	t.captures = t.countCaptures()
+	if err := t.translate(); err != nil {
+		return "", false, err
+	}
+	return t.out.String(), !t.approximated, nil
+}
+
+// compilePattern compiles an ECMA-262 pattern with Go regexp
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	rex, _, err := compileExactPattern(pattern)
+	return rex, err
+}
+
+// compileExactPattern compiles an ECMA-262 pattern like compilePattern, and tells if the compiled regexp
+// matches exactly the strings matched by the original pattern
+func compileExactPattern(pattern string) (*regexp.Regexp, bool, error) {
+	translated, exact, err := translateExactPattern(pattern)
+	if err != nil {
+		return nil, false, err
+	}
+	rex, err := regexp.Compile(translated)
+	return rex, exact, err
+}
+
+func (t *ecmaTranslator) unsupported(construct string) error {
+	return fmt.Errorf("unsupported construct %q at offset %d in pattern %q", construct, t.pos, t.pattern)
+}
+
+func (t *ecmaTranslator) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(t.src[t.pos:]), prefix)
+}
+
+// countCaptures counts the capture groups in the pattern, so backreferences may be told apart from octal escapes
+func (t *ecmaTranslator) countCaptures() int {
+	count := 0
+	inClass := false
+	for i := 0; i < len(t.src); i++ {
+		switch r := t.src[i]; {
+		case r == '\\':
+			i++
+		case inClass:
+			inClass = r != ']'
+		case r == '[':
+			inClass = true
+		case r == '(':
+			rest := string(t.src[i+1:])
+			if !strings.HasPrefix(rest, "?") ||
+				(strings.HasPrefix(rest, "?<") && !strings.HasPrefix(rest, "?<=") && !strings.HasPrefix(rest, "?<!")) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func (t *ecmaTranslator) translate() error {
+	var open []ecmaOpen
+	for t.pos < len(t.src) {
+		r := t.src[t.pos]
+		switch {
+		case r == '\\':
+			if err := t.escape(false); err != nil {
+				return err
+			}
+		case r == '[':
+			if err := t.class(); err != nil {
+				return err
+			}
+		case r == '(':
+			opened := ecmaOpen{start: t.out.Len()}
+			switch {
+			case t.hasPrefix("(?=") || t.hasPrefix("(?!"):
+				opened.lookaround = true
+				t.pos += 3
+			case t.hasPrefix("(?<=") || t.hasPrefix("(?<!"):
+				opened.lookaround = true
+				t.pos += 4
+			case t.hasPrefix("(?<"):
+				end := strings.IndexRune(string(t.src[t.pos:]), '>')
+				if end < 0 {
+					return t.unsupported("(?<")
+				}
+				name := string(t.src[t.pos+3 : t.pos+end])
+				opened.group = &ecmaGroup{name: name}
+				t.groups = append(t.groups, opened.group)
+				t.out.WriteString("(?P<" + name + ">")
+				t.pos += end + 1
+			case t.hasPrefix("(?"):
+				t.out.WriteString("(?")
+				t.pos += 2
+			default:
+				opened.group = &ecmaGroup{}
+				t.groups = append(t.groups, opened.group)
+				t.out.WriteRune('(')
+				t.pos++
+			}
+			open = append(open, opened)
+		case r == ')':
+			if len(open) == 0 {
+				return t.unsupported(")")
+			}
+			opened := open[len(open)-1]
+			open = open[:len(open)-1]
+			t.out.WriteRune(')')
+			t.pos++
+			translated := t.out.String()
+			if opened.group != nil {
+				// the source of the group, without its parentheses
+				inner := translated[opened.start:]
+				if strings.HasPrefix(inner, "(?P<") {
+					opened.group.source = inner[strings.IndexRune(inner, '>')+1 : len(inner)-1]
+				} else {
+					opened.group.source = inner[1 : len(inner)-1]
+				}
+				opened.group.closed = true
+			}
+			if opened.lookaround {
+				t.approximated = true
+				debugLog("lookaround %q dropped from pattern %q", translated[opened.start:], t.pattern)
+				t.out.Reset()
+				t.out.WriteString(translated[:opened.start])
+				t.skipQuantifier()
+			}
+		default:
+			t.out.WriteRune(r)
+			t.pos++
+		}
+	}
+	if len(open) > 0 {
+		return t.unsupported("(")
+	}
+	return nil
+}
+
+// skipQuantifier skips a quantifier applied to a dropped lookaround
+func (t *ecmaTranslator) skipQuantifier() {
+	if t.pos >= len(t.src) {
+		return
+	}
+	switch t.src[t.pos] {
+	case '*', '+', '?':
+		t.pos++
+	case '{':
+		end := strings.IndexRune(string(t.src[t.pos:]), '}')
+		if end < 0 {
+			return
+		}
+		if _, err := strconv.Atoi(strings.Replace(string(t.src[t.pos+1:t.pos+end]), ",", "", 1)); err != nil {
+			return
+		}
+		t.pos += end + 1
+	default:
+		return
+	}
+	if t.pos < len(t.src) && t.src[t.pos] == '?' {
+		t.pos++
+	}
+}
+
+// class translates a character class
+func (t *ecmaTranslator) class() error {
+	switch {
+	case t.hasPrefix("[^]"):
+		t.out.WriteString(`[\x{0}-\x{10FFFF}]`)
+		t.pos += 3
+		return nil
+	case t.hasPrefix("[]"):
+		return t.unsupported("[]")
+	}
+
+	t.out.WriteRune('[')
+	t.pos++
+	if t.hasPrefix("^") {
+		t.out.WriteRune('^')
+		t.pos++
+	}
+	for t.pos < len(t.src) {
+		switch r := t.src[t.pos]; r {
+		case ']':
+			t.out.WriteRune(']')
+			t.pos++
+			return nil
+		case '\\':
+			if err := t.escape(true); err != nil {
+				return err
+			}
+		case '[':
+			// a literal in ECMA-262, which may start a POSIX class in Go
+			t.out.WriteString(`\[`)
+			t.pos++
+		default:
+			t.out.WriteRune(r)
+			t.pos++
+		}
+	}
+	return t.unsupported("[")
+}
+
+// escape translates an escape sequence, either in a character class or not
+func (t *ecmaTranslator) escape(inClass bool) error {
+	if t.pos+1 >= len(t.src) {
+		return t.unsupported(`\`)
+	}
+	t.pos++
+	r := t.src[t.pos]
+	t.pos++
+
+	switch {
+	case strings.ContainsRune("dDwWsSfnrtv", r):
+		t.out.WriteRune('\\')
+		t.out.WriteRune(r)
+	case r == 'b' || r == 'B':
+		if inClass && r == 'b' {
+			t.writeCodePoint(0x08)
+			return nil
+		}
+		if inClass {
+			return t.unsupported(`\B`)
+		}
+		t.out.WriteRune('\\')
+		t.out.WriteRune(r)
+	case r == 'u':
+		return t.unicodeEscape()
+	case r == 'x' && t.isHex(t.pos, 2):
+		t.out.WriteString(`\x` + string(t.src[t.pos:t.pos+2]))
+		t.pos += 2
+	case r == 'c' && t.pos < len(t.src) && isASCIILetter(t.src[t.pos]):
+		t.writeCodePoint(t.src[t.pos] % 32)
+		t.pos++
+	case r == 'p' || r == 'P':
+		return t.propertyEscape(r)
+	case r == 'k' && !inClass && t.hasPrefix("<"):
+		end := strings.IndexRune(string(t.src[t.pos:]), '>')
+		if end < 0 {
+			return t.unsupported(`\k`)
+		}
+		name := string(t.src[t.pos+1 : t.pos+end])
+		t.pos += end + 1
+		for _, group := range t.groups {
+			if group.name == name {
+				t.backreference(group)
+				return nil
+			}
+		}
+		return t.unsupported(`\k<` + name + `>`)
+	case r >= '1' && r <= '9' && !inClass:
+		start := t.pos - 1
+		for t.pos < len(t.src) && unicode.IsDigit(t.src[t.pos]) {
+			t.pos++
+		}
+		n, _ := strconv.Atoi(string(t.src[start:t.pos]))
+		if n <= t.captures {
+			if n <= len(t.groups) {
+				t.backreference(t.groups[n-1])
+			} else {
+				// a forward reference matches the empty string
+				t.out.WriteString("(?:)")
+			}
+			return nil
+		}
+		t.pos = start
+		t.octalEscape()
+	case r >= '0' && r <= '7':
+		t.pos--
+		t.octalEscape()
+	case isASCIILetter(r) || unicode.IsDigit(r):
+		// an identity escape in ECMA-262, which may have another meaning in Go
+		t.out.WriteRune(r)
+	case r < unicode.MaxASCII && (unicode.IsPunct(r) || unicode.IsSymbol(r)):
+		t.out.WriteRune('\\')
+		t.out.WriteRune(r)
+	default:
+		t.out.WriteRune(r)
+	}
+	return nil
+}
+
+// unicodeEscape translates \uXXXX, \u{X...} and surrogate pairs
+func (t *ecmaTranslator) unicodeEscape() error {
+	if t.hasPrefix("{") {
+		end := strings.IndexRune(string(t.src[t.pos:]), '}')
+		if end < 0 {
+			return t.unsupported(`\u{`)
+		}
+		code, err := strconv.ParseUint(string(t.src[t.pos+1:t.pos+end]), 16, 32)
+		if err != nil || code > unicode.MaxRune {
+			return t.unsupported(`\u` + string(t.src[t.pos:t.pos+end+1]))
+		}
+		t.writeCodePoint(rune(code))
+		t.pos += end + 1
+		return nil
+	}
+	if !t.isHex(t.pos, 4) {
+		// an identity escape
+		t.out.WriteRune('u')
+		return nil
+	}
+	code, _ := strconv.ParseUint(string(t.src[t.pos:t.pos+4]), 16, 32)
+	t.pos += 4
+	if code >= 0xD800 && code <= 0xDBFF && t.hasPrefix(`\u`) && t.isHex(t.pos+2, 4) {
+		low, _ := strconv.ParseUint(string(t.src[t.pos+2:t.pos+6]), 16, 32)
+		if low >= 0xDC00 && low <= 0xDFFF {
+			t.writeCodePoint(rune((code-0xD800)<<10 + (low - 0xDC00) + 0x10000))
+			t.pos += 6
+			return nil
+		}
+	}
+	t.writeCodePoint(rune(code))
+	return nil
+}
+
+// propertyEscape translates \p{...} and \P{...}, for the categories and scripts known to Go
+func (t *ecmaTranslator) propertyEscape(r rune) error {
+	if !t.hasPrefix("{") {
+		t.out.WriteRune(r)
+		return nil
+	}
+	end := strings.IndexRune(string(t.src[t.pos:]), '}')
+	if end < 0 {
+		return t.unsupported(`\` + string(r) + `{`)
+	}
+	property := string(t.src[t.pos+1 : t.pos+end])
+	t.pos += end + 1
+	name := property
+	if i := strings.IndexRune(property, '='); i >= 0 {
+		switch property[:i] {
+		case "General_Category", "gc", "Script", "sc", "Script_Extensions", "scx":
+			name = property[i+1:]
+		default:
+			return t.unsupported(`\` + string(r) + `{` + property + `}`)
+		}
+	}
+	if _, isCategory := unicode.Categories[name]; !isCategory {
+		if _, isScript := unicode.Scripts[name]; !isScript {
+			return t.unsupported(`\` + string(r) + `{` + property + `}`)
+		}
+	}
+	t.out.WriteString(`\` + string(r) + `{` + name + `}`)
+	return nil
+}
+
+// octalEscape translates a legacy octal escape, or an identity escape of a digit
+func (t *ecmaTranslator) octalEscape() {
+	code := 0
+	digits := 0
+	for t.pos < len(t.src) && digits < 3 && t.src[t.pos] >= '0' && t.src[t.pos] <= '7' && code*8+int(t.src[t.pos]-'0') <= 0377 {
+		code = code*8 + int(t.src[t.pos]-'0')
+		t.pos++
+		digits++
+	}
+	if digits == 0 {
+		// \8 and \9 stand for the digit
+		t.out.WriteRune(t.src[t.pos])
+		t.pos++
+		return
+	}
+	t.writeCodePoint(rune(code))
+}
+
+// backreference approximates a backreference by a copy of the referenced group
+func (t *ecmaTranslator) backreference(group *ecmaGroup) {
+	if !group.closed {
+		// a reference to an enclosing group matches the empty string
+		t.out.WriteString("(?:)")
+		return
+	}
+	t.approximated = true
+	debugLog("backreference to %q approximated in pattern %q", group.source, t.pattern)
+	t.out.WriteString("(?:" + nonCapturing(group.source) + ")")
+}
+
+// nonCapturing turns the capture groups of a translated pattern into non-capturing groups,
+// so that a copy of the pattern does not duplicate named groups
+func nonCapturing(translated string) string {
+	var out strings.Builder
+	inClass := false
+	for i := 0; i < len(translated); i++ {
+		c := translated[i]
+		switch {
+		case c == '\\' && i+1 < len(translated):
+			out.WriteString(translated[i : i+2])
+			i++
+			continue
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			out.WriteByte(c)
+			// a closing bracket right after the opening one is a literal
+			if i+1 < len(translated) && translated[i+1] == '^' {
+				i++
+				out.WriteByte('^')
+			}
+			if i+1 < len(translated) && translated[i+1] == ']' {
+				i++
+				out.WriteByte(']')
+			}
+			continue
+		case c == '(' && strings.HasPrefix(translated[i:], "(?P<"):
+			out.WriteString("(?:")
+			i += strings.IndexByte(translated[i:], '>')
+			continue
+		case c == '(' && !strings.HasPrefix(translated[i:], "(?"):
+			out.WriteString("(?:")
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+func (t *ecmaTranslator) writeCodePoint(r rune) {
+	fmt.Fprintf(&t.out, `\x{%X}`, r)
+}
+
+func (t *ecmaTranslator) isHex(pos, n int) bool {
+	if pos+n > len(t.src) {
+		return false
+	}
+	for _, r := range t.src[pos : pos+n] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,394 @@
+package stubs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+)
+
+// standardEdgeCases is the argument of withEdgeCase which enables the standard catalogue of edge cases
+const standardEdgeCases = "standard"
+
+// maxEdgeCaseLength is the largest length validation for which strings of the boundary length are built
+const maxEdgeCaseLength = 1 << 16
+
+// surrogatePair is a character outside the basic multilingual plane, i.e. encoded as a surrogate pair in UTF-16
+const surrogatePair = "\U0001F600"
+
+var (
+	// textEdgeCases are remarkable strings, regardless of the format
+	textEdgeCases = []string{
+		"",
+		" ",
+		"\u00e9",       // precomposed character
+		"e\u0301",      // same character, with a combining accent
+		surrogatePair,  // 1 rune, 2 UTF-16 code units
+		"\u200b",       // zero-width space
+		"line\nbreak",  // control character
+		"\"quoted\\\"", // escaped characters in JSON
+	}
+
+	// formatEdgeCases are remarkable strings for some formats
+	formatEdgeCases = map[string][]string{
+		"date": {
+			"1970-01-01",
+			"2000-02-29", // leap day, on a century
+			"2024-02-29",
+			"0001-01-01",
+			"9999-12-31",
+		},
+		"date-time": {
+			"1970-01-01T00:00:00Z",
+			"1969-12-31T23:59:59.999Z",
+			"2024-02-29T12:00:00Z",
+			// daylight saving time transitions in Europe/Paris: the last second before the skipped hour,
+			// the first second after it, then the same time twice in the repeated hour
+			"2021-03-28T01:59:59+01:00",
+			"2021-03-28T03:00:00+02:00",
+			"2021-10-31T02:30:00+02:00",
+			"2021-10-31T02:30:00+01:00",
+			"9999-12-31T23:59:59Z",
+		},
+		"uuid": {
+			"00000000-0000-0000-0000-000000000000",
+			"ffffffff-ffff-ffff-ffff-ffffffffffff",
+		},
+	}
+)
+
+// isStandard tells if the standard catalogue of edge cases is enabled, i.e. withEdgeCase is either true or lists "standard"
+func (b *boolOrSlice) isStandard() bool {
+	return b.Enabled && (len(b.Args) == 0 || b.Contains(standardEdgeCases))
+}
+
+// edgeCaseSamples builds samples from the edge cases of a descriptor and its nested descriptors.
+//
+// The standard catalogue is enabled by the args of the generator or of the descriptor, or locally by
+// the args of a nested descriptor. Literal edge values specified with withEdgeCase are converted to the
+// type of the descriptor which specifies them.
+//
+// Nested edge cases are injected in a value generated at random, from the options where they have been found.
+// Each sample is labelled with the validations it fails.
+func (s *Generator) edgeCaseSamples(generator *generators, key string, descriptor interface{}, gopts GeneratorOpts, args boolOrSlice) ([]Sample, error) {
+	type found struct {
+		path       string
+		value      interface{}
+		violations StubMode
+	}
+	values := make([]found, 0, 10)
+	var err error
+	walkOpts(gopts, func(path, _ string, opts GeneratorOpts) {
+		if err != nil {
+			return
+		}
+		edges := opts.Args().WithEdgeCase
+		if path == "" {
+			edges = args
+		}
+		if args.isStandard() || edges.isStandard() {
+			for _, value := range standardEdgeCasesFor(opts) {
+				if !acceptsEdgeCase(value, opts) {
+					continue
+				}
+				values = append(values, found{path: path, value: value, violations: violationsOf(value, opts)})
+			}
+		}
+		if !edges.Enabled {
+			return
+		}
+		for _, literal := range edges.Args {
+			if literal == standardEdgeCases {
+				continue
+			}
+			var value interface{}
+			value, err = literalEdgeCase(generator, literal, opts)
+			if err != nil {
+				return
+			}
+			violations := violationsOf(value, opts)
+			if !matchesFormat(value, opts) {
+				// there is no dedicated mode for formats
+				violations |= Invalid
+			}
+			values = append(values, found{path: path, value: value, violations: violations})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	debugLog("%d edge cases found for %q", len(values), key)
+
+	samples := make([]Sample, 0, len(values))
+	for _, v := range values {
+		value, err := s.genSample(generator, key, descriptor, gopts, nil, map[string]injection{v.path: {value: v.value}}, v.violations)
+		if errors.Is(err, errNotApplied) {
+			debugLog("edge case %v at %q cannot be injected", v.value, v.path)
+			continue
+		}
+		if s.Verify && (errors.Is(err, ErrNoValid) || errors.Is(err, ErrNoInvalid)) {
+			debugLog("edge case %v at %q fails verification: %v", v.value, v.path, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, Sample{Value: value, Valid: v.violations == Valid, Violations: v.violations, Origin: OriginEdgeCase})
+	}
+	return samples, nil
+}
+
+// literalEdgeCase converts a literal edge value from the x-datagen extension to the type of a descriptor
+func literalEdgeCase(generator *generators, literal string, opts GeneratorOpts) (interface{}, error) {
+	switch opts.Type() {
+	case "integer", "number", "string", "boolean":
+		value, err := generator.typedValue(literal, opts.Type(), opts.Format())
+		if err != nil {
+			return nil, fmt.Errorf("invalid edge case %q for type %s: %v", literal, opts.Type(), err)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("literal edge cases are not supported for type %s: %q", opts.Type(), literal)
+	}
+}
+
+// standardEdgeCasesFor yields the standard edge cases for the type and format of a descriptor.
+//
+// NaN and infinite numbers are not part of the catalogue, since JSON cannot represent them.
+func standardEdgeCasesFor(opts GeneratorOpts) []interface{} {
+	var edges []interface{}
+	switch opts.Type() {
+	case "integer":
+		edges = integerEdgeCases(opts)
+	case "number":
+		edges = numberEdgeCases(opts)
+	case "string":
+		edges = stringEdgeCases(opts)
+	case "boolean":
+		edges = []interface{}{false, true}
+	case "array":
+		edges = []interface{}{[]interface{}{}}
+	default:
+		return nil
+	}
+
+	// remove duplicates, such as a bound equal to 0
+	seen := make(map[string]bool, len(edges))
+	res := make([]interface{}, 0, len(edges))
+	for _, edge := range edges {
+		k := fmt.Sprintf("%T:%v", edge, edge)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		res = append(res, edge)
+	}
+	return res
+}
+
+// integerEdgeCases yields 0, ±1, the limits of the integer type and the integers on both sides of each bound
+func integerEdgeCases(opts GeneratorOpts) []interface{} {
+	candidates := []float64{0, 1, -1}
+	if minimum, exclusive, defined := opts.Minimum(); defined {
+		first := smallestIntAbove(minimum, exclusive)
+		candidates = append(candidates, first-1, first)
+	}
+	if maximum, exclusive, defined := opts.Maximum(); defined {
+		last := largestIntBelow(maximum, exclusive)
+		candidates = append(candidates, last, last+1)
+	}
+
+	format := opts.Format()
+	edges := make([]interface{}, 0, len(candidates)+2)
+	for _, candidate := range candidates {
+		if value, ok := integerOf(candidate, format); ok {
+			edges = append(edges, value)
+		}
+	}
+
+	switch format {
+	case "int32":
+		edges = append(edges, int32(math.MinInt32), int32(math.MaxInt32))
+	case "uint32":
+		edges = append(edges, uint32(math.MaxUint32))
+	case "uint64":
+		edges = append(edges, uint64(math.MaxUint64))
+	default:
+		edges = append(edges, int64(math.MinInt64), int64(math.MaxInt64))
+	}
+	return edges
+}
+
+// integerOf converts an integral float to the go type for an integer format, provided it fits this type
+func integerOf(f float64, format string) (interface{}, bool) {
+	switch format {
+	case "int32":
+		if f < math.MinInt32 || f > math.MaxInt32 {
+			return nil, false
+		}
+		return int32(f), true
+	case "uint32":
+		if f < 0 || f > math.MaxUint32 {
+			return nil, false
+		}
+		return uint32(f), true
+	case "uint64":
+		if f < 0 || f >= 1<<64 {
+			return nil, false
+		}
+		return uint64(f), true
+	default:
+		if f < -1<<63 || f >= 1<<63 {
+			return nil, false
+		}
+		return int64(f), true
+	}
+}
+
+// numberEdgeCases yields 0, -0, ±1, the smallest and largest magnitudes of the floating point type,
+// and each bound surrounded by its closest neighbours
+func numberEdgeCases(opts GeneratorOpts) []interface{} {
+	bounds := make([]float64, 0, 2)
+	if minimum, _, defined := opts.Minimum(); defined {
+		bounds = append(bounds, minimum)
+	}
+	if maximum, _, defined := opts.Maximum(); defined {
+		bounds = append(bounds, maximum)
+	}
+
+	if format := opts.Format(); format == "float" || format == "float32" {
+		edges := []interface{}{
+			float32(0), float32(math.Copysign(0, -1)), float32(1), float32(-1),
+			float32(math.SmallestNonzeroFloat32), float32(-math.SmallestNonzeroFloat32),
+			float32(math.MaxFloat32), float32(-math.MaxFloat32),
+		}
+		for _, bound := range bounds {
+			b := float32(bound)
+			edges = append(edges, math.Nextafter32(b, float32(math.Inf(-1))), b, math.Nextafter32(b, float32(math.Inf(1))))
+		}
+		return edges
+	}
+
+	edges := []interface{}{
+		float64(0), math.Copysign(0, -1), float64(1), float64(-1),
+		math.SmallestNonzeroFloat64, -math.SmallestNonzeroFloat64,
+		math.MaxFloat64, -math.MaxFloat64,
+	}
+	for _, bound := range bounds {
+		edges = append(edges, math.Nextafter(bound, math.Inf(-1)), bound, math.Nextafter(bound, math.Inf(1)))
+	}
+	return edges
+}
+
+// stringEdgeCases yields remarkable strings for the format, as well as strings with lengths on both sides of each bound.
+//
+// Strings of the maximum length are built with ASCII characters, then with surrogate pairs, since the length
+// of a string is a number of characters, not of bytes or UTF-16 code units.
+func stringEdgeCases(opts GeneratorOpts) []interface{} {
+	edges := make([]interface{}, 0, 20)
+	for _, str := range formatEdgeCases[opts.Format()] {
+		edges = append(edges, str)
+	}
+	for _, str := range textEdgeCases {
+		edges = append(edges, str)
+	}
+	if minLength, defined := opts.MinLength(); defined && minLength > 0 && minLength <= maxEdgeCaseLength {
+		edges = append(edges, strings.Repeat("a", int(minLength)-1), strings.Repeat("a", int(minLength)))
+	}
+	if maxLength, defined := opts.MaxLength(); defined && maxLength <= maxEdgeCaseLength {
+		edges = append(edges,
+			strings.Repeat("a", int(maxLength)),
+			strings.Repeat(surrogatePair, int(maxLength)),
+			strings.Repeat("a", int(maxLength)+1),
+		)
+	}
+	return edges
+}
+
+// acceptsEdgeCase tells if a standard edge case is relevant for a descriptor.
+//
+// Edge cases which do not match the format or the enum of the descriptor are discarded: these validations
+// are checked by GenValidationChecks.
+func acceptsEdgeCase(value interface{}, opts GeneratorOpts) bool {
+	if !matchesFormat(value, opts) {
+		return false
+	}
+	if enum, defined := opts.Enum(); defined && validate.Enum("", "", value, enum) != nil {
+		return false
+	}
+	return true
+}
+
+// matchesFormat tells if a value is valid for the format of a descriptor. Unknown formats accept any value.
+func matchesFormat(value interface{}, opts GeneratorOpts) bool {
+	str, isString := value.(string)
+	format := opts.Format()
+	if !isString || format == "" || !strfmt.Default.ContainsName(format) {
+		return true
+	}
+	return strfmt.Default.Validates(format, str)
+}
+
+// matchesPattern tells if a string matches an ECMA-262 pattern, translated to Go regexp as when generating values
+func matchesPattern(str, pattern string) bool {
+	rex, err := compilePattern(pattern)
+	if err != nil {
+		debugLog("pattern %q cannot be translated: %v", pattern, err)
+		return validate.Pattern("", "", str, pattern) == nil
+	}
+	return rex.MatchString(str)
+}
+
+// violationsOf yields the validations of a descriptor which a value fails.
+//
+// Only the validations of the descriptor itself are checked, not those of nested descriptors.
+func violationsOf(value interface{}, opts GeneratorOpts) StubMode {
+	var violations StubMode
+	v := reflect.ValueOf(value)
+
+	if _, isNumber := numberValue(v); isNumber {
+		if maximum, exclusive, defined := opts.Maximum(); defined && validate.MaximumNativeType("", "", value, maximum, exclusive) != nil {
+			violations |= InvalidMaximum
+		}
+		if minimum, exclusive, defined := opts.Minimum(); defined && validate.MinimumNativeType("", "", value, minimum, exclusive) != nil {
+			violations |= InvalidMinimum
+		}
+		if multipleOf, defined := opts.MultipleOf(); defined && validate.MultipleOfNativeType("", "", value, multipleOf) != nil {
+			violations |= InvalidMultipleOf
+		}
+	}
+
+	if str, isString := value.(string); isString {
+		if maxLength, defined := opts.MaxLength(); defined && validate.MaxLength("", "", str, maxLength) != nil {
+			violations |= InvalidMaxLength
+		}
+		if minLength, defined := opts.MinLength(); defined && validate.MinLength("", "", str, minLength) != nil {
+			violations |= InvalidMinLength
+		}
+		if pattern, defined := opts.Pattern(); defined && !matchesPattern(str, pattern) {
+			violations |= InvalidPattern
+		}
+	}
+
+	if v.Kind() == reflect.Slice {
+		size := int64(v.Len())
+		if maxItems, defined := opts.MaxItems(); defined && validate.MaxItems("", "", size, maxItems) != nil {
+			violations |= InvalidMaxItems
+		}
+		if minItems, defined := opts.MinItems(); defined && validate.MinItems("", "", size, minItems) != nil {
+			violations |= InvalidMinItems
+		}
+		if opts.UniqueItems() && validate.UniqueItems("", "", value) != nil {
+			violations |= InvalidUniqueItems
+		}
+	}
+
+	if enum, defined := opts.Enum(); defined && validate.Enum("", "", value, enum) != nil {
+		violations |= InvalidEnum
+	}
+	return violations
+}
@@ -0,0 +1,169 @@
+package stubs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// maxEnumRetries is the number of attempts made to tilt an enum member into a value which is not in the enum
+const maxEnumRetries = 100
+
+// genEnum draws a value among the members of an enum validation.
+//
+// Members are drawn uniformly, unless weighted by the "enum" argument of the x-datagen extension.
+// Weights are keyed by the string representation of members: members without a weight are never drawn.
+//
+// Values are converted according to the type and format of the descriptor, e.g. a JSON number in the enum
+// of an integer with format int32 yields an int32.
+//
+// In InvalidEnum mode, a drawn member is tilted to a value of the same type, which is not in the enum.
+func (g *generators) genEnum(opts GeneratorOpts) (interface{}, error) {
+	enum, defined := opts.Enum()
+	if !defined { // Safeguard
+		if opts.Mode().Has(InvalidEnum) {
+			return nil, ErrNoInvalid
+		}
+		return nil, ErrNoValid
+	}
+
+	values := make([]interface{}, 0, len(enum))
+	for _, member := range enum {
+		value, err := g.typedValue(member, opts.Type(), opts.Format())
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum value %v for type %s: %v", member, opts.Type(), err)
+		}
+		values = append(values, value)
+	}
+
+	candidates := values
+	weights := make([]float64, 0, len(values))
+	if enumWeights := opts.Args().Enum; len(enumWeights) > 0 {
+		candidates = make([]interface{}, 0, len(values))
+		for _, value := range values {
+			if w := enumWeights[fmt.Sprintf("%v", value)]; w > 0 {
+				candidates = append(candidates, value)
+				weights = append(weights, w)
+			}
+		}
+		if len(candidates) == 0 {
+			debugLog("no enum value is eligible with weights %v", enumWeights)
+			return nil, ErrNoValid
+		}
+	} else {
+		for range values {
+			weights = append(weights, 1)
+		}
+	}
+
+	index, err := g.entropy.Weighted(weights)
+	if err != nil {
+		return nil, err
+	}
+	picked := candidates[index]
+	if !opts.Mode().Has(InvalidEnum) {
+		return picked, nil
+	}
+	return g.tiltEnum(picked, values)
+}
+
+// typedValue converts a value from a spec to the go type corresponding to a type and format
+func (g *generators) typedValue(value interface{}, typ, format string) (interface{}, error) {
+	switch typ {
+	case "integer":
+		switch format {
+		case "int32":
+			v, err := g.conv.Int64(value)
+			return int32(v), err
+		case "uint32":
+			v, err := g.conv.Uint64(value)
+			return uint32(v), err
+		case "uint64":
+			return g.conv.Uint64(value)
+		default:
+			return g.conv.Int64(value)
+		}
+	case "number":
+		v, err := g.conv.Float64(value)
+		if format == "float" || format == "float32" {
+			return float32(v), err
+		}
+		return v, err
+	case "boolean":
+		return g.conv.Bool(value)
+	case "string":
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+		return fmt.Sprintf("%v", value), nil
+	default:
+		return value, nil
+	}
+}
+
+// tiltEnum alters a member of an enum just enough to produce a value of the same type, which is not in the enum.
+//
+// Strings get one character replaced, numbers are shifted by the smallest integer amount and booleans are negated.
+func (g *generators) tiltEnum(member interface{}, enum []interface{}) (interface{}, error) {
+	switch v := member.(type) {
+	case string:
+		for i := 0; i < maxEnumRetries; i++ {
+			runes := []rune(v)
+			letter := rune('a' + g.entropy.IntN(26))
+			if len(runes) == 0 {
+				runes = append(runes, letter)
+			} else {
+				runes[g.entropy.IntN(len(runes))] = letter
+			}
+			if tilted := string(runes); !containsValue(enum, tilted) {
+				return tilted, nil
+			}
+		}
+	case bool:
+		if !containsValue(enum, !v) {
+			return !v, nil
+		}
+	default:
+		value := reflect.ValueOf(member)
+		for shift := int64(1); shift <= maxEnumRetries; shift++ {
+			for _, delta := range []int64{shift, -shift} {
+				if tilted, ok := shiftNumber(value, delta); ok && !containsValue(enum, tilted) {
+					return tilted, nil
+				}
+			}
+		}
+	}
+	return nil, ErrNoInvalid
+}
+
+// shiftNumber adds delta to a numeric value, preserving its type.
+//
+// It returns false when the value is not a number or when the result overflows its type.
+func shiftNumber(value reflect.Value, delta int64) (interface{}, bool) {
+	res := reflect.New(value.Type()).Elem()
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := value.Int() + delta
+		if res.OverflowInt(n) || (delta > 0) != (n > value.Int()) {
+			return nil, false
+		}
+		res.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if delta < 0 && value.Uint() < uint64(-delta) {
+			return nil, false
+		}
+		n := value.Uint() + uint64(delta)
+		if res.OverflowUint(n) || (delta > 0 && n < value.Uint()) {
+			return nil, false
+		}
+		res.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f := value.Float() + float64(delta)
+		if res.OverflowFloat(f) {
+			return nil, false
+		}
+		res.SetFloat(f)
+	default:
+		return nil, false
+	}
+	return res.Interface(), true
+}
@@ -0,0 +1,243 @@
+package stubs
+
+import (
+	"reflect"
+)
+
+// Decision is a decision taken by an inference rule about the value generator to use
+type Decision struct {
+	// Rule is the origin of the decision: "x-datagen", "schema", "type", "format", "pattern", "fuzzy", "items", "enum",
+	// or "field-name" when the value generator is found from the name of the field
+	Rule string
+	// Generator is the name of the value generator decided by the rule
+	Generator string
+	// Score is the confidence of a fuzzy decision. Other rules decide with certainty, with a score of 1.
+	//
+	// A fuzzy decision below the confidence threshold is reported with its score, but is never elected.
+	Score float64
+	// Elected is true for the decision which prevails
+	Elected bool
+}
+
+// Explanation describes how values are generated for a descriptor, or for a value nested in this descriptor
+type Explanation struct {
+	// Path locates a nested value, e.g. "/address/city", "/items" or "/0" for the first item of a tuple.
+	// Segments are escaped as in JSON pointers, e.g. "/a~1b" for a property named "a/b".
+	// The path of the root value is empty.
+	Path string
+	// Field is the name of the field, when known
+	Field  string
+	Type   string
+	Format string
+	// Generator is the name of the value generator eventually used, or empty when no value generator is found
+	Generator string
+	// Decisions are the decisions taken to select the value generator, in the order the rules ran
+	Decisions []Decision
+	// Args are the arguments set for the value generator, by the x-datagen extension or the Generator.
+	// Only arguments with a value are reported, by their name in x-datagen.
+	Args map[string]interface{}
+	// Constraints are the validations which apply to generated values, by their name in JSON schema
+	Constraints map[string]interface{}
+	// Mode is the generation mode set by the x-datagen extension
+	Mode StubMode
+	// Nested explains the generation of values nested in this one, such as properties or items
+	Nested []*Explanation
+}
+
+// Explain traces the decisions taken to generate values for a descriptor, and for the values nested in it.
+//
+// This is meant to understand surprising values, without having to dig into debug logs. No value is generated.
+func (s *Generator) Explain(key string, descriptor interface{}) (*Explanation, error) {
+	generator, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer s.release(generator)
+
+	gopts, err := s.genOptsFor(generator, key, descriptor)
+	if err != nil || gopts == nil {
+		return nil, err
+	}
+
+	var root *Explanation
+	explanations := make(map[string]*Explanation, 10)
+	walkOpts(gopts, func(path, parent string, opts GeneratorOpts) {
+		explanation := generator.explain(path, opts)
+		explanations[path] = explanation
+		if path == "" {
+			root = explanation
+			return
+		}
+		if p, ok := explanations[parent]; ok {
+			p.Nested = append(p.Nested, explanation)
+		}
+	})
+	return root, nil
+}
+
+// explain runs the inference rules on some generation options and resolves the value generator to use
+func (g *generators) explain(path string, opts GeneratorOpts) *Explanation {
+	explanation := &Explanation{
+		Path:        path,
+		Field:       opts.FieldName(),
+		Type:        opts.Type(),
+		Format:      opts.Format(),
+		Args:        argsOf(opts.Args()),
+		Constraints: constraintsOf(opts),
+		Mode:        opts.Mode(),
+	}
+	explanation.Decisions = opts.explain()
+
+	key, _, found := g.resolve(opts)
+	if !found {
+		return explanation
+	}
+	explanation.Generator = key
+	if _, _, byName := g.lookup(opts.Name()); !byName {
+		// none of the decisions designates a known generator
+		for i := range explanation.Decisions {
+			explanation.Decisions[i].Elected = false
+		}
+		explanation.Decisions = append(explanation.Decisions, Decision{Rule: "field-name", Generator: key, Score: 1, Elected: true})
+	}
+	return explanation
+}
+
+// explain runs the inference rules, like Infer, and reports their decisions.
+//
+// Each rule decides only once: decisions are merged as Infer does, so the last decision naming a generator prevails.
+func (g *genOpts) explain() []Decision {
+	if g.datagen {
+		return []Decision{{Rule: "x-datagen", Generator: g.name, Score: 1, Elected: true}}
+	}
+
+	res := make([]Decision, 0, len(g.rules))
+	decisions := make([]basicGeneratorOpts, 0, len(g.rules))
+	elected := -1
+	for _, rule := range g.rules {
+		var (
+			decision basicGeneratorOpts
+			guess    string
+			score    = 1.0
+		)
+		if fuzzy, isFuzzy := rule.(*fuzzyRuler); isFuzzy {
+			decision, guess, score = fuzzy.decideWithScore()
+		} else {
+			decision = rule.Decide()
+		}
+		switch {
+		case decision != nil:
+			decisions = append(decisions, decision)
+			if decision.Name() != "" {
+				elected = len(res)
+			}
+			res = append(res, Decision{Rule: ruleName(rule), Generator: decision.Name(), Score: score})
+		case guess != "":
+			// report near misses, which are never elected
+			res = append(res, Decision{Rule: ruleName(rule), Generator: guess, Score: score})
+		}
+	}
+	if elected >= 0 {
+		res[elected].Elected = true
+	}
+
+	g.merge(decisions)
+	return res
+}
+
+// ruleName yields the name of an inference rule, as reported in explanations
+func ruleName(rule ruler) string {
+	switch rule.(type) {
+	case *schemaRuler:
+		return "schema"
+	case *typeRuler:
+		return "type"
+	case *formatRuler:
+		return "format"
+	case *patternRuler:
+		return "pattern"
+	case *fuzzyRuler:
+		return "fuzzy"
+	case *itemsRuler:
+		return "items"
+	case *enumRuler:
+		return "enum"
+	default:
+		return reflect.TypeOf(rule).String()
+	}
+}
+
+// argsOf yields the arguments with a value, by their name in the x-datagen extension
+func argsOf(args *argTags) map[string]interface{} {
+	res := make(map[string]interface{}, 5)
+	v := reflect.ValueOf(args).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		name := v.Type().Field(i).Tag.Get("mapstructure")
+		switch arg := field.Interface().(type) {
+		case boolOrMap:
+			if len(arg.Args) > 0 {
+				res[name] = arg.Args
+			} else {
+				res[name] = arg.Enabled
+			}
+		case boolOrSlice:
+			if len(arg.Args) > 0 {
+				res[name] = arg.Args
+			} else {
+				res[name] = arg.Enabled
+			}
+		default:
+			res[name] = reflect.Indirect(field).Interface()
+		}
+	}
+	return res
+}
+
+// constraintsOf yields the validations of some generation options, by their name in JSON schema
+func constraintsOf(opts GeneratorOpts) map[string]interface{} {
+	res := make(map[string]interface{}, 5)
+	if maximum, exclusive, defined := opts.Maximum(); defined {
+		res["maximum"] = maximum
+		if exclusive {
+			res["exclusiveMaximum"] = true
+		}
+	}
+	if minimum, exclusive, defined := opts.Minimum(); defined {
+		res["minimum"] = minimum
+		if exclusive {
+			res["exclusiveMinimum"] = true
+		}
+	}
+	if multipleOf, defined := opts.MultipleOf(); defined {
+		res["multipleOf"] = multipleOf
+	}
+	if maxLength, defined := opts.MaxLength(); defined {
+		res["maxLength"] = maxLength
+	}
+	if minLength, defined := opts.MinLength(); defined {
+		res["minLength"] = minLength
+	}
+	if pattern, defined := opts.Pattern(); defined {
+		res["pattern"] = pattern
+	}
+	if maxItems, defined := opts.MaxItems(); defined {
+		res["maxItems"] = maxItems
+	}
+	if minItems, defined := opts.MinItems(); defined {
+		res["minItems"] = minItems
+	}
+	if opts.UniqueItems() {
+		res["uniqueItems"] = true
+	}
+	if enum, defined := opts.Enum(); defined {
+		res["enum"] = enum
+	}
+	if opts.Required() {
+		res["required"] = true
+	}
+	return res
+}
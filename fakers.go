@@ -3,14 +3,15 @@ package stubs
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"regexp"
 	"regexp/syntax"
 	"strings"
+	"sync"
 	"time"
 
 	randomdata "github.com/Pallinder/go-randomdata"
 	"github.com/asaskevich/govalidator"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/manveru/faker"
 	regen "github.com/zach-klippenstein/goregen"
 
@@ -23,11 +24,18 @@ import (
 type ValueGenerator func(GeneratorOpts) (interface{}, error)
 
 type generators struct {
-	faker     *faker.Faker
-	conv      Converter
-	gens      map[string]ValueGenerator
-	regenArgs *regen.GeneratorArgs
-	entropy   *randomGenerator
+	faker      *faker.Faker
+	conv       Converter
+	gens       map[string]ValueGenerator
+	regenArgs  *regen.GeneratorArgs
+	entropy    *randomGenerator
+	custom     map[string]ValueGenerator // custom value generators registered on the Generator, by name and alias
+	randomdata *rand.Rand                // source of entropy for randomdata
+	now        func() time.Time          // reference time for dates
+	path       string                    // path of the value being generated, relative to the root value
+	overrides  map[string]StubMode       // modes overriding generation options, by path
+	injections map[string]injection      // values injected instead of generated values, by path
+	visited    map[string]bool           // paths where an override or an injection has been applied
 }
 
 var (
@@ -35,6 +43,9 @@ var (
 	ErrNoValid error
 	// ErrNoInvalid indicates to the caller that value generator could not abide by specified invalid mode flags
 	ErrNoInvalid error
+
+	// errNotApplied indicates that no value has been generated at some overridden or injected path
+	errNotApplied = fmt.Errorf("no value generated at an overridden path")
 )
 
 func init() {
@@ -42,42 +53,49 @@ func init() {
 	ErrNoInvalid = fmt.Errorf("No invalid value could be generated")
 }
 
+// randomdataMutex serializes calls to randomdata, which draws from a package-level source
+var randomdataMutex sync.Mutex
+
 // newgenerator instantiate a new generator for a specific language.
 //
 // By default, language is english ("en").
+//
+// All sources of entropy (faker, randomdata, regen and our own random generator) derive from the seed,
+// so the same seed yields the same values.
 // TODO: replace lang by GeneralOps
-func newGenerator(lang string) (*generators, error) {
+func newGenerator(lang string, seed int64) (*generators, error) {
 	if lang == "" {
 		lang = "en"
 	}
-	debugLog("new generator for lang=%s", lang)
+	debugLog("new generator for lang=%s, seed=%d", lang, seed)
 	faker, err := faker.New(lang)
 	if err != nil {
 		return nil, err
 	}
-	// TODO: set seeds on underlying fakers
+	source := rand.New(rand.NewSource(seed))
+	faker.Rand = rand.New(rand.NewSource(source.Int63()))
 	g := &generators{
 		faker: faker,
 		conv:  defaultConverter{},
 		regenArgs: &regen.GeneratorArgs{
-			Flags: syntax.Perl,
+			RngSource: rand.NewSource(source.Int63()),
+			Flags:     syntax.Perl,
 		},
-		entropy: newRandomGenerator(randomOpts{
-			// TODO: from opts
-			AutoSeed: true,
-		},
-		),
+		randomdata: rand.New(rand.NewSource(source.Int63())),
+		entropy:    newRandomGenerator(randomOpts{Seed: source.Int63()}),
+		now:        time.Now,
 	}
 	g.makeGenerators()
 	return g, nil
 }
 
-// makeGenerators initializes the map of supported stub generators
+// makeGenerators initializes the map of supported stub generators.
+// New generators must also be listed in generatorNames.
 func (g *generators) makeGenerators() {
 	g.gens = map[string]ValueGenerator{
 		"amount":            g.genAmount,
 		"small-amount":      g.genSmallAmount,
-		"adjective":         g.string(randomdata.Noun),
+		"adjective":         g.string(g.randomdataString(randomdata.Noun)),
 		"bool":              g.bool,
 		"characters":        g.intString(g.faker.Characters),
 		"city":              g.string(g.faker.City),
@@ -88,7 +106,7 @@ func (g *generators) makeGenerators() {
 		"company-slogan":    g.string(g.faker.CompanyCatchPhrase),
 		"company-suffix":    g.string(g.faker.CompanySuffix),
 		"country":           g.string(g.faker.Country),
-		"credit-card":       g.fromPattern(govalidator.CreditCard),
+		"credit-card":       g.genCreditCard,
 		"domain":            g.string(g.faker.DomainName),
 		"domain-suffix":     g.string(g.faker.DomainSuffix),
 		"double":            g.numGenFloat64,
@@ -100,12 +118,12 @@ func (g *generators) makeGenerators() {
 		"hostname":          g.string(g.faker.DomainWord),
 		"int32":             g.numGenInt32,
 		"int64":             g.numGenInt64,
-		"ip":                g.altws(randomdata.IpV4Address, randomdata.IpV6Address),
-		"ipv4":              g.string(randomdata.IpV4Address),
-		"ipv6":              g.string(randomdata.IpV6Address),
-		"isbn":              g.altwsp(govalidator.ISBN10, govalidator.ISBN13),
-		"isbn10":            g.fromPattern(govalidator.ISBN10),
-		"isbn13":            g.fromPattern(govalidator.ISBN13),
+		"ip":                g.altws(g.randomdataString(randomdata.IpV4Address), g.randomdataString(randomdata.IpV6Address)),
+		"ipv4":              g.string(g.randomdataString(randomdata.IpV4Address)),
+		"ipv6":              g.string(g.randomdataString(randomdata.IpV6Address)),
+		"isbn":              g.genISBN,
+		"isbn10":            g.genISBN10,
+		"isbn13":            g.genISBN13,
 		"job-title":         g.string(g.faker.JobTitle),
 		"landline":          g.string(g.faker.PhoneNumber),
 		"last-name":         g.string(g.faker.LastName),
@@ -116,7 +134,7 @@ func (g *generators) makeGenerators() {
 		"name":              g.string(g.faker.Name),
 		"name-prefix":       g.string(g.faker.NamePrefix),
 		"name-suffix":       g.string(g.faker.NameSuffix),
-		"noun":              g.string(randomdata.Noun),
+		"noun":              g.string(g.randomdataString(randomdata.Noun)),
 		"number":            g.numGenFloat64,
 		"paragraph":         g.intBoolString(g.faker.Paragraph),
 		"paragraphs":        g.intBoolStrings(g.faker.Paragraphs),
@@ -127,8 +145,8 @@ func (g *generators) makeGenerators() {
 		"secondary-address": g.string(g.faker.SecondaryAddress),
 		"sentence":          g.intBoolString(g.faker.Sentence),
 		"sentences":         g.intBoolStrings(g.faker.Sentences),
-		"silly-name":        g.string(randomdata.SillyName),
-		"ssn":               g.fromPattern(govalidator.SSN),
+		"silly-name":        g.string(g.randomdataString(randomdata.SillyName)),
+		"ssn":               g.fromPattern("^[0-9]{3}-[0-9]{2}-[0-9]{4}$"),
 		"state":             g.string(g.faker.StateAbbr),
 		"state-name":        g.string(g.faker.State),
 		"street-address":    g.string(g.faker.StreetAddress),
@@ -144,15 +162,32 @@ func (g *generators) makeGenerators() {
 		"word":              g.string(func() string { return g.faker.Words(1, false)[0] }),
 		"words":             g.intBoolStrings(g.faker.Words),
 		"date":              g.dateGen,
+		"birthdate":         g.birthdateGen,
+		"recent-datetime":   g.recentDateTimeGen,
+		"age":               g.ageGen,
 		"datetime":          g.dateTimeGen,
 		"duration":          g.durationGen,
+		"iso8601-duration":  g.genISO8601Duration,
+		"base64":            g.genBase64,
+		"bsonobjectid":      g.fromPattern("^[0-9a-f]{24}$"),
+		"cidr":              g.genCIDR,
+		"country-code":      g.pick(countryCodes),
+		"currency-code":     g.pick(currencyCodes),
+		"password":          g.fromPattern("^[A-Za-z0-9!#$%&*+=?@^_~-]{12,16}$"),
+		"ulid":              g.fromPattern("^[0-7][0-9A-HJKMNP-TV-Z]{25}$"),
+		"uri":               g.genURI,
+		"uuid7":             g.fromPattern("^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"),
+
+		// composite generators
+		"object": g.genObject,
+		"array":  g.genArray,
+		"enum":   g.genEnum,
 	}
 
 	/* TODO:
 	* add near date
 	* add near date-time, timestamps, update-time...
 	* add short duration
-	* add slices
 	 */
 }
 
@@ -167,13 +202,25 @@ func normalizeGeneratorName(str string) string {
 // For selects an appropriate value generator for the generation option.
 func (g *generators) For(opts GeneratorOpts) (ValueGenerator, bool) {
 	debugLog("looking for valueGenerator for option: %s", opts.Name())
-	if gen, ok := g.gens[normalizeGeneratorName(opts.Name())]; ok {
-		return gen, true
+	key, gen, ok := g.resolve(opts)
+	if !ok {
+		return nil, false
 	}
-	if gen, ok := g.gens[normalizeGeneratorName(swag.ToCommandName(opts.FieldName()))]; ok {
+	if isCompositeGenerator(key) {
 		return gen, true
 	}
-	return nil, false
+	return g.fitLength(key, gen), true
+}
+
+// resolve looks up the value generator for the generation options, by name or else by field name.
+// It returns the name under which the value generator is registered.
+func (g *generators) resolve(opts GeneratorOpts) (string, ValueGenerator, bool) {
+	for _, name := range []string{opts.Name(), swag.ToCommandName(opts.FieldName())} {
+		if key, gen, ok := g.lookup(name); ok {
+			return key, gen, true
+		}
+	}
+	return "", nil, false
 }
 
 // altws returns a values generator which chooses randomly among a list of generating functions
@@ -188,38 +235,62 @@ func (g *generators) altws(fns ...func() string) ValueGenerator {
 }
 
 // altwsp returns a values generator which chooses randomly among a list of generating patterns.
-// Patterns are generated using regen.Generate().
+// Patterns are generated using regen.
 //
 // Example:
 //  altwsp(govalidator.ISBN10, govalidator.ISBN13)
 func (g *generators) altwsp(patterns ...string) ValueGenerator {
 	return func(opts GeneratorOpts) (interface{}, error) {
 		idx := g.entropy.IntN(len(patterns))
-		return regen.Generate(patterns[idx])
+		rexgen, err := regen.NewGenerator(patterns[idx], g.regenArgs)
+		if err != nil {
+			return nil, err
+		}
+		return rexgen.Generate(), nil
 	}
 }
 
 // fromPattern returns a value generator based on pattern generation.
-// Patterns are generated using regen.Generate().
+// Patterns are generated using regen.Generate(), with repetitions bounded to fit length validations.
+//
+// When pattern is empty, the pattern is taken from the options. In InvalidPattern mode, the generated value
+// is the closest string which does not match the pattern.
 func (g *generators) fromPattern(pattern string) ValueGenerator {
 	return func(opts GeneratorOpts) (interface{}, error) {
-		if pattern == "" {
-			// use option-defined pattern
-			p, defined := opts.Pattern()
-			if defined {
-				pattern = p
+		p := pattern
+		lo, hi := int64(0), int64(-1)
+		if opts != nil {
+			if p == "" {
+				// use option-defined pattern
+				p, _ = opts.Pattern()
 			}
+			var err error
+			if lo, hi, err = lengthBounds(opts); err != nil {
+				return nil, err
+			}
+		}
+		// translate the pattern from ECMA-262 and check it is valid
+		translated, err := translatePattern(p)
+		if err != nil {
+			return nil, err
 		}
-		// check pattern is valid
-		if _, err := regexp.Compile(pattern); err != nil {
+		if _, err = regexp.Compile(translated); err != nil {
 			return nil, err
 		}
-		debugLog("calling regen with pattern: %q", pattern)
-		rexgen, err := regen.NewGenerator(pattern, g.regenArgs)
+		bounded, err := g.boundPattern(translated, lo, hi)
 		if err != nil {
 			return nil, err
 		}
-		return rexgen.Generate(), nil
+		debugLog("calling regen with pattern: %q", bounded)
+		rexgen, err := regen.NewGenerator(bounded, g.regenArgs)
+		if err != nil {
+			return nil, err
+		}
+		value := rexgen.Generate()
+		if opts == nil || !opts.Mode().Has(InvalidPattern) {
+			return value, nil
+		}
+		return g.tiltPattern(value, opts)
 	}
 }
 
@@ -229,6 +300,16 @@ func (g *generators) stringError(fn func() (string, error)) ValueGenerator {
 	}
 }
 
+// randomdataString wraps a randomdata function so that it draws from the source of entropy of this generator
+func (g *generators) randomdataString(fn func() string) func() string {
+	return func() string {
+		randomdataMutex.Lock()
+		defer randomdataMutex.Unlock()
+		randomdata.CustomRand(g.randomdata)
+		return fn()
+	}
+}
+
 func (g *generators) string(fn func() string) ValueGenerator {
 	return func(opts GeneratorOpts) (interface{}, error) {
 		return fn(), nil
@@ -301,39 +382,52 @@ func (g *generators) bool(opts GeneratorOpts) (interface{}, error) {
 }
 
 func (g *generators) genAmount(opts GeneratorOpts) (interface{}, error) {
-	args := opts.Args()
-	if args.Max == nil {
-		args.Max = swag.Float64(StubsDefaultMaxAmount)
-	}
-	if args.Min == nil || swag.Float64Value(args.Min) < 0 {
-		args.Min = swag.Float64(StubsDefaultMinAmount)
-	}
-	opts.SetArgs(args)
-	spew.Dump(opts)
-	res, err := g.numGenFloat64(opts)
-	spew.Dump(res)
-	if err != nil {
-		return 0, err
-	}
-	f := res.(float64)
-	return math.Trunc(f*float64(100)) / float64(100), nil
+	return g.genAmountIn(opts, StubsDefaultMinAmount, StubsDefaultMaxAmount)
 }
 
 func (g *generators) genSmallAmount(opts GeneratorOpts) (interface{}, error) {
-	args := opts.Args()
+	return g.genAmountIn(opts, StubsDefaultMinSmallAmount, StubsDefaultMaxSmallAmount)
+}
+
+// genAmountIn generates a currency amount, with at most 2 decimals, in a default range.
+//
+// The default range is extended whenever it does not overlap the minimum or maximum of the descriptor,
+// and amounts are rounded to 2 decimals only when rounding does not alter validity.
+func (g *generators) genAmountIn(opts GeneratorOpts, defaultMin, defaultMax float64) (interface{}, error) {
+	original := *opts.Args()
+	defer opts.SetArgs(&original)
+
+	args := original
+	width := defaultMax - defaultMin
 	if args.Max == nil {
-		args.Max = swag.Float64(StubsDefaultMaxSmallAmount)
+		max := defaultMax
+		if minimum, _, defined := opts.Minimum(); defined && minimum >= max {
+			max = minimum + width
+		}
+		args.Max = swag.Float64(max)
 	}
 	if args.Min == nil || swag.Float64Value(args.Min) < 0 {
-		args.Min = swag.Float64(StubsDefaultMinSmallAmount)
+		min := defaultMin
+		if maximum, _, defined := opts.Maximum(); defined && maximum <= min {
+			min = 0
+			if maximum <= 0 {
+				min = maximum - width
+			}
+		}
+		args.Min = swag.Float64(min)
 	}
-	opts.SetArgs(args)
+	opts.SetArgs(&args)
+
 	res, err := g.numGenFloat64(opts)
 	if err != nil {
 		return 0, err
 	}
 	f := res.(float64)
-	return math.Trunc(f*float64(100)) / float64(100), nil
+	rounded := math.Trunc(f*float64(100)) / float64(100)
+	if violationsOf(rounded, opts) != violationsOf(f, opts) {
+		return f, nil
+	}
+	return rounded, nil
 }
 
 func (g *generators) numGenFloat64(opts GeneratorOpts) (interface{}, error) {
@@ -536,38 +630,285 @@ func (g *generators) numGenFloat32(opts GeneratorOpts) (interface{}, error) {
 }
 
 func (g *generators) numGenInt64(opts GeneratorOpts) (interface{}, error) {
-	return g.entropy.Int64(0, 0), nil
+	return g.numGenInteger(opts, math.MinInt64, math.MaxInt64, defaultMinInt64, defaultMaxInt64)
 }
 
 func (g *generators) numGenInt32(opts GeneratorOpts) (interface{}, error) {
-	return g.entropy.Int32(0, 0), nil
+	res, err := g.numGenInteger(opts, math.MinInt32, math.MaxInt32, defaultMinInt32, defaultMaxInt32)
+	if err != nil {
+		return int32(0), err
+	}
+	return int32(res), nil
 }
 
 func (g *generators) numGenUint32(opts GeneratorOpts) (interface{}, error) {
-	return g.entropy.Uint32(0, 0), nil
+	res, err := g.numGenInteger(opts, 0, math.MaxUint32, int64(defaultMinUint32), defaultMaxUint32)
+	if err != nil {
+		return uint32(0), err
+	}
+	return uint32(res), nil
 }
 
 func (g *generators) numGenUint64(opts GeneratorOpts) (interface{}, error) {
-	return g.entropy.Uint64(0, 0), nil
+	// NOTE: values above math.MaxInt64 are not generated
+	res, err := g.numGenInteger(opts, 0, math.MaxInt64, int64(defaultMinUint64), defaultMaxUint64)
+	if err != nil {
+		return uint64(0), err
+	}
+	return uint64(res), nil
+}
+
+// numGenInteger generates an integer within the limits of its type [typeMin, typeMax].
+//
+// Unless otherwise specified by the "min" and "max" args, values are preferably taken in [defaultMin, defaultMax].
+// Validations (minimum, maximum, multipleOf) always prevail over args.
+//
+// Invalid modes InvalidMinimum and InvalidMaximum are mutually exclusive. They may be combined with InvalidMultipleOf.
+func (g *generators) numGenInteger(opts GeneratorOpts, typeMin, typeMax, defaultMin, defaultMax int64) (int64, error) {
+	// lo, hi is the preferred range
+	lo, hi := defaultMin, defaultMax
+	// vlo, vhi is the range allowed by validations
+	vlo, vhi := typeMin, typeMax
+	var step int64 = 1
+	var mode StubMode
+	definedMin, definedMax, definedMult := false, false, false
+
+	if opts != nil {
+		// check options
+		args := opts.Args()
+		if args.Min != nil {
+			lo = clampInt64(math.Ceil(*args.Min), typeMin, typeMax)
+		}
+		if args.Max != nil {
+			hi = clampInt64(math.Floor(*args.Max), typeMin, typeMax)
+		}
+		multipleOf := float64(1)
+		if args.MultipleOf != nil {
+			multipleOf = *args.MultipleOf
+		}
+
+		// check validations
+		var minCheck, maxCheck float64
+		var exclusiveMin, exclusiveMax bool
+		minCheck, exclusiveMin, definedMin = opts.Minimum()
+		if definedMin {
+			m := smallestIntAbove(minCheck, exclusiveMin)
+			if m > float64(typeMax) {
+				debugLog("minimum %v is out of range for this integer type", minCheck)
+				return 0, ErrNoValid
+			}
+			vlo = clampInt64(m, typeMin, typeMax)
+		}
+		maxCheck, exclusiveMax, definedMax = opts.Maximum()
+		if definedMax {
+			m := largestIntBelow(maxCheck, exclusiveMax)
+			if m < float64(typeMin) {
+				debugLog("maximum %v is out of range for this integer type", maxCheck)
+				return 0, ErrNoValid
+			}
+			vhi = clampInt64(m, typeMin, typeMax)
+		}
+		var multipleOfCheck float64
+		if multipleOfCheck, definedMult = opts.MultipleOf(); definedMult {
+			multipleOf = multipleOfCheck
+		}
+		var ok bool
+		if step, ok = integerStep(multipleOf); !ok {
+			debugLog("no integer is a multiple of %v", multipleOf)
+			return 0, ErrNoValid
+		}
+
+		mode = opts.Mode()
+	}
+
+	// clear edge cases
+	switch {
+	case mode.Has(InvalidMinimum) && !definedMin: // Safeguard
+		return 0, ErrNoInvalid
+	case mode.Has(InvalidMaximum) && !definedMax: // Safeguard
+		return 0, ErrNoInvalid
+	case mode.Has(InvalidMultipleOf) && (!definedMult || step == 1): // Safeguard
+		return 0, ErrNoInvalid
+
+		// mutually exclusive failures
+	case mode.Has(InvalidMinimum):
+		if vlo == typeMin {
+			return 0, ErrNoInvalid
+		}
+		vlo, vhi = typeMin, vlo-1
+	case mode.Has(InvalidMaximum):
+		if vhi == typeMax {
+			return 0, ErrNoInvalid
+		}
+		vlo, vhi = vhi+1, typeMax
+	}
+
+	// the preferred range is narrowed by validations
+	if lo < vlo {
+		lo = vlo
+	}
+	if hi > vhi {
+		hi = vhi
+	}
+	if lo > hi {
+		// preferences contradict validations: validations prevail
+		lo, hi = vlo, vhi
+	}
+	if lo > hi {
+		debugLog("contradictory range for integer: [%d, %d]", lo, hi)
+		if mode.Has(InvalidMinimum) || mode.Has(InvalidMaximum) {
+			return 0, ErrNoInvalid
+		}
+		return 0, ErrNoValid
+	}
+
+	if mode.Has(InvalidMultipleOf) {
+		// pick any value, then shift it to the nearest non-multiple
+		res := g.entropy.Int64Between(lo, hi)
+		if res%step != 0 {
+			return res, nil
+		}
+		switch {
+		case res < hi:
+			return res + 1, nil
+		case res > lo:
+			return res - 1, nil
+		default:
+			return 0, ErrNoInvalid
+		}
+	}
+
+	if step == 1 {
+		return g.entropy.Int64Between(lo, hi), nil
+	}
+	// pick a multiple of step in range
+	klo, khi := ceilDiv(lo, step), floorDiv(hi, step)
+	if klo > khi {
+		debugLog("no multiple of %d in range [%d, %d]", step, lo, hi)
+		if mode != Valid {
+			return 0, ErrNoInvalid
+		}
+		return 0, ErrNoValid
+	}
+	return step * g.entropy.Int64Between(klo, khi), nil
+}
+
+// smallestIntAbove yields the smallest integer valid for a minimum validation
+func smallestIntAbove(minimum float64, exclusive bool) float64 {
+	m := math.Ceil(minimum)
+	if exclusive && m == minimum {
+		m++
+	}
+	return m
+}
+
+// largestIntBelow yields the largest integer valid for a maximum validation
+func largestIntBelow(maximum float64, exclusive bool) float64 {
+	m := math.Floor(maximum)
+	if exclusive && m == maximum {
+		m--
+	}
+	return m
+}
+
+// clampInt64 converts a float to an integer in range [lo, hi]
+func clampInt64(f float64, lo, hi int64) int64 {
+	switch {
+	case f <= float64(lo):
+		return lo
+	case f >= float64(hi):
+		return hi
+	default:
+		return int64(f)
+	}
+}
+
+// integerStep determines the smallest positive integer which is a multiple of multipleOf.
+//
+// e.g. with multipleOf 2.5, integer values must be multiples of 5.
+func integerStep(multipleOf float64) (int64, bool) {
+	if multipleOf <= 0 {
+		return 1, true
+	}
+	for k := float64(1); k <= 1000; k++ {
+		if step := k * multipleOf; isMultipleOf(step, 1) && step >= 1 {
+			return int64(math.Round(step)), true
+		}
+	}
+	return 0, false
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func ceilDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) == (b < 0) {
+		q++
+	}
+	return q
 }
 
 func (g *generators) dateGen(opts GeneratorOpts) (interface{}, error) {
 	offset := g.entropy.Int64(-10000, +10000) // TODO: options to qualify dates
-	t := time.Now().AddDate(0, 0, int(offset))
+	t := g.now().AddDate(0, 0, int(offset))
 	var d strfmt.Date = strfmt.Date(t)
 	return d.String(), nil
 }
 
 func (g *generators) dateTimeGen(opts GeneratorOpts) (interface{}, error) {
 	offset := g.entropy.Int64(-10000, +10000) // TODO: options to qualify dates
-	t := time.Now().AddDate(0, 0, int(offset))
+	t := g.now().AddDate(0, 0, int(offset))
 	t.Add(time.Duration(offset))
 	offset = g.entropy.Int64(-1000000000, +1000000000) // TODO: options to qualify date-times
 	var dt strfmt.DateTime = strfmt.DateTime(t)
 	return dt.String(), nil
 }
 
+// birthdateGen generates the date of birth of an adult, between StubsDefaultMinAge and StubsDefaultMaxAge years ago
+func (g *generators) birthdateGen(opts GeneratorOpts) (interface{}, error) {
+	years := g.entropy.Int64Between(StubsDefaultMinAge, StubsDefaultMaxAge-1)
+	t := g.now().AddDate(-int(years), 0, -int(g.entropy.Int64Between(0, 364)))
+	return strfmt.Date(t).String(), nil
+}
+
+// recentDateTimeGen generates a date-time in the recent past, up to StubsDefaultRecentDays ago
+func (g *generators) recentDateTimeGen(opts GeneratorOpts) (interface{}, error) {
+	offset := g.entropy.Int64Between(0, StubsDefaultRecentDays*int64(24*time.Hour/time.Second))
+	t := g.now().Add(-time.Duration(offset) * time.Second)
+	return strfmt.DateTime(t).String(), nil
+}
+
+// ageGen generates the age of a person, preferably between StubsDefaultMinAge and StubsDefaultMaxAge.
+//
+// Like other integer generators, the value is typed after the format of the descriptor, and defaults to int64.
+func (g *generators) ageGen(opts GeneratorOpts) (interface{}, error) {
+	var format string
+	if opts != nil {
+		format = opts.Format()
+	}
+	switch format {
+	case "int32":
+		res, err := g.numGenInteger(opts, 0, math.MaxInt32, StubsDefaultMinAge, StubsDefaultMaxAge)
+		return int32(res), err
+	case "uint32":
+		res, err := g.numGenInteger(opts, 0, math.MaxUint32, StubsDefaultMinAge, StubsDefaultMaxAge)
+		return uint32(res), err
+	case "uint64":
+		res, err := g.numGenInteger(opts, 0, math.MaxInt64, StubsDefaultMinAge, StubsDefaultMaxAge)
+		return uint64(res), err
+	default:
+		return g.numGenInteger(opts, 0, math.MaxInt64, StubsDefaultMinAge, StubsDefaultMaxAge)
+	}
+}
+
+// durationGen generates a positive duration, up to maxDurationHours, e.g. 12h3m15s
 func (g *generators) durationGen(opts GeneratorOpts) (interface{}, error) {
-	offset := g.entropy.Int64(0, 0)
+	offset := g.entropy.Int64Between(1, maxDurationHours*int64(time.Hour/time.Second)) * int64(time.Second)
 	return strfmt.Duration(offset).String(), nil
 }
@@ -1,13 +1,20 @@
 package stubs
 
 import (
+	"math"
+	"reflect"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerators_SimpleGenerators(t *testing.T) {
-	g, err := newGenerator("")
+	g, err := newGenerator("", 0)
 	assert.NoError(t, err)
 	for i := 0; i < 20; i++ {
 		b, err := g.bool(nil)
@@ -40,3 +47,287 @@ func TestGenerators_SimpleGenerators(t *testing.T) {
 
 	}
 }
+
+// registeredFormats lists the names of the formats registered in strfmt.Default
+func registeredFormats(t *testing.T) []string {
+	// the registry does not expose its formats: peek at its content
+	data := reflect.ValueOf(strfmt.Default).Elem().FieldByName("data")
+	require.True(t, data.IsValid(), "unexpected implementation of strfmt.Default")
+	names := make([]string, 0, data.Len())
+	for i := 0; i < data.Len(); i++ {
+		names = append(names, data.Index(i).FieldByName("Name").String())
+	}
+	require.NotEmpty(t, names)
+	return names
+}
+
+func TestGeneratorNames(t *testing.T) {
+	gen, err := newGenerator("", 0)
+	require.NoError(t, err)
+
+	keys := make([]string, 0, len(gen.gens))
+	for key := range gen.gens {
+		if !isCompositeGenerator(key) {
+			keys = append(keys, key)
+		}
+	}
+	assert.ElementsMatch(t, keys, generatorNames)
+}
+
+func TestGenerators_Formats(t *testing.T) {
+	gen := Generator{Language: "en"}
+	for _, format := range append(registeredFormats(t), "date-time", "duration", "duration-iso8601", "binary") {
+		schema := spec.StringProperty()
+		schema.Format = format
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("", schema)
+			if !assert.NoError(t, err, format) {
+				break
+			}
+			str, ok := res.(string)
+			if !assert.True(t, ok, "%s: expected a string, got %T", format, res) {
+				break
+			}
+			if strfmt.Default.ContainsName(format) {
+				assert.True(t, strfmt.Default.Validates(format, str), "%s: %q", format, str)
+			}
+		}
+	}
+}
+
+func TestGenerators_FromPattern(t *testing.T) {
+	gen := Generator{Language: "en"}
+	generator, err := gen.acquire()
+	require.NoError(t, err)
+	defer gen.release(generator)
+	optsFor := func(js string) GeneratorOpts {
+		gopts, err := gen.genOptsFor(generator, "", testSchema(t, js))
+		require.NoError(t, err)
+		gopts.Infer()
+		return gopts
+	}
+	fromPattern := generator.gens["pattern"]
+
+	// repetitions are bounded by length validations, without regenerating values
+	for _, toPin := range []struct {
+		js     string
+		lo, hi int
+	}{
+		{`{"type": "string", "pattern": "^[a-z]+$", "maxLength": 5}`, 1, 5},
+		{`{"type": "string", "pattern": "^[a-z]*$", "minLength": 3, "maxLength": 4}`, 3, 4},
+		{`{"type": "string", "pattern": "^[A-Z]+-[0-9]*$", "maxLength": 8}`, 2, 8},
+		{`{"type": "string", "pattern": "^(ab)+c?$", "minLength": 5, "maxLength": 7}`, 5, 7},
+		{`{"type": "string", "pattern": "^[a-z]{2,10}$", "maxLength": 4, "x-datagen": {"mode": 16}}`, 5, 10},
+	} {
+		opts := optsFor(toPin.js)
+		pattern, _ := opts.Pattern()
+		rex := regexp.MustCompile(pattern)
+		for i := 0; i < 50; i++ {
+			res, err := fromPattern(opts)
+			require.NoError(t, err, toPin.js)
+			str := res.(string)
+			assert.True(t, rex.MatchString(str), "%s: %q", toPin.js, str)
+			assert.True(t, len(str) >= toPin.lo && len(str) <= toPin.hi, "%s: %q", toPin.js, str)
+		}
+	}
+
+	_, err = fromPattern(optsFor(`{"type": "string", "pattern": "^[a-z]{6}$", "maxLength": 5}`))
+	assert.Equal(t, ErrNoValid, err)
+
+	// the pattern is taken from the options at every call
+	for _, pattern := range []string{"^[a-c]{3}$", "^[0-9]{4}$", "^x+$"} {
+		res, err := fromPattern(optsFor(`{"type": "string", "pattern": "` + pattern + `"}`))
+		require.NoError(t, err)
+		assert.Regexp(t, pattern, res)
+	}
+
+	// invalid values are close to values matching the pattern
+	opts := optsFor(`{"type": "string", "pattern": "^[A-Z]{3}-[0-9]{4}$", "x-datagen": {"mode": 64}}`)
+	for i := 0; i < 20; i++ {
+		res, err := fromPattern(opts)
+		require.NoError(t, err)
+		assert.NotRegexp(t, "^[A-Z]{3}-[0-9]{4}$", res)
+		assert.Len(t, res, 8)
+	}
+
+	// approximated patterns cannot be checked, so their values are never tilted
+	for _, pattern := range []string{`^(?!ab)[a-z]{2}$`, `^(a|b)\\1$`} {
+		_, err = fromPattern(optsFor(`{"type": "string", "pattern": "` + pattern + `", "x-datagen": {"mode": 64}}`))
+		assert.ErrorIs(t, err, ErrNoInvalid, pattern)
+	}
+}
+
+func TestTranslatePattern(t *testing.T) {
+	for _, toPin := range []struct {
+		pattern, expected string
+	}{
+		{`^[a-z]+\d{2}$`, `^[a-z]+\d{2}$`},
+		{`^é\u{1F600}\uD83D\uDE00$`, `^é\x{1F600}\x{1F600}$`},
+		{`^\cJ\0$`, `^\x{A}\x{0}$`},
+		{`^[\b\-\/]\/$`, `^[\x{8}\-\/]\/$`},
+		{`^(?<year>\d{4})-\k<year>$`, `^(?P<year>\d{4})-(?:\d{4})$`},
+		{`^(a|b)c\1$`, `^(a|b)c(?:a|b)$`},
+		{`(?<x>a)\k<x>`, `(?P<x>a)(?:a)`},
+		{`^(?<x>(?<y>[(a])|b)\k<x>\k<y>$`, `^(?P<x>(?P<y>[(a])|b)(?:(?:[(a])|b)(?:[(a])$`},
+		{`^((a)\()\1$`, `^((a)\()(?:(?:a)\()$`},
+		{`^(?=.*[A-Z])(?!.*\s)[A-Za-z0-9]{8,}$`, `^[A-Za-z0-9]{8,}$`},
+		{`(?<=\$)\d+(?<!0)`, `\d+`},
+		{`^[^][[]\e\a$`, `^[\x{0}-\x{10FFFF}][\[]ea$`},
+		{`^\p{Script=Greek}\p{L}+$`, `^\p{Greek}\p{L}+$`},
+		{`^\12\8$`, `^\x{A}8$`},
+	} {
+		translated, err := translatePattern(toPin.pattern)
+		if assert.NoError(t, err, toPin.pattern) {
+			assert.Equal(t, toPin.expected, translated, toPin.pattern)
+			_, err = regexp.Compile(translated)
+			assert.NoError(t, err, toPin.pattern)
+		}
+	}
+
+	for _, pattern := range []string{`^[]$`, `^\p{Emoji_Presentation}$`, `^(a$`, `^a)$`, `^[a-z$`, `\`} {
+		_, err := translatePattern(pattern)
+		if assert.Error(t, err, pattern) {
+			assert.Contains(t, err.Error(), "unsupported construct")
+		}
+	}
+
+	// ECMA-262 patterns are supported by generators
+	gen := Generator{Language: "en"}
+	schema := testSchema(t, `{"type": "string", "pattern": "^(?=.*\\d)\\u0041[\\u0061-\\u007a]{3}(?<n>\\d)\\k<n>$"}`)
+	for i := 0; i < 20; i++ {
+		res, err := gen.GenSchema("", schema)
+		require.NoError(t, err)
+		assert.Regexp(t, `^A[a-z]{3}\d\d$`, res)
+	}
+}
+
+func TestFuzzyRuler(t *testing.T) {
+	assert.Equal(t, []string{"customer", "email", "address", "http", "server", "url", "ipv4"},
+		tokenize("customerEmailAddress, HTTPServer_url ipv4"))
+
+	for _, toPin := range []struct {
+		title, description string
+		expected           string
+	}{
+		{"customerEmailAddress", "", "email"},
+		{"email", "", "email"},
+		{"billing_zip_code", "", "postcode"},
+		{"billingZipcode", "", "postcode"},
+		{"countryCode", "", "country-code"},
+		{"country", "", "country"},
+		{"firstName", "", "first-name"},
+		{"last_name", "", "last-name"},
+		{"userName", "", "user-name"},
+		{"login", "", "user-name"},
+		{"mobilePhone", "", "mobile"},
+		{"phoneNumber", "", "landline"},
+		{"serverIpAddress", "", "ipv4"},
+		{"macAddress", "", "mac-address"},
+		{"companyName", "", "company"},
+		{"jobTitle", "", "job-title"},
+		{"city", "the city where the customer lives", "city"},
+		{"lastLoginDate", "", "date"},
+		{"streetAddress", "", "street-address"},
+		{"ssn", "", "ssn"},
+		{"contact", "The email address of the contact", "email"},
+		{"addr", "The IP address of the host", "ipv4"},
+		{"customeremail", "", "email"},
+		// no confident match
+		{"description", "", ""},
+		{"createdBy", "", ""},
+		{"status", "The state of the order", ""},
+		{"flag", "", ""},
+		{"count", "The number of items", ""},
+	} {
+		rule := newFuzzyRulerFor(&spec.Schema{SchemaProps: spec.SchemaProps{Title: toPin.title, Description: toPin.description}})
+		decision := rule.Decide()
+		if toPin.expected == "" {
+			assert.Nil(t, decision, "%s: %v", toPin.title, decision)
+			continue
+		}
+		if assert.NotNil(t, decision, toPin.title) {
+			assert.Equal(t, toPin.expected, decision.Name(), toPin.title)
+		}
+	}
+
+	// the election is deterministic
+	for i := 0; i < 20; i++ {
+		key, _ := mergeAndElectProposal(map[string]float64{"city": 1, "state": 1, "country": 0.5}, map[string]float64{"zip": 0.5})
+		assert.Equal(t, "city", key)
+	}
+}
+
+func TestFuzzyRuler_Types(t *testing.T) {
+	for _, toPin := range []struct {
+		js       string
+		expected string
+	}{
+		{`{"type": "number", "title": "price"}`, "amount"},
+		{`{"type": "number", "format": "double", "title": "unitCost"}`, "amount"},
+		{`{"type": "number", "title": "bankFees"}`, "small-amount"},
+		{`{"type": "number", "title": "latitude"}`, "latitude"},
+		{`{"type": "number", "title": "customerAge"}`, "age"},
+		{`{"type": "integer", "format": "int32", "title": "customerAge"}`, "age"},
+		{`{"type": "string", "format": "date", "title": "birthday"}`, "birthdate"},
+		{`{"type": "string", "format": "date", "title": "dateOfBirth"}`, "birthdate"},
+		{`{"type": "string", "format": "date-time", "title": "updatedAt"}`, "recent-datetime"},
+		{`{"type": "string", "format": "date-time", "title": "timestamp"}`, "recent-datetime"},
+		{`{"type": "string", "title": "birthday"}`, "birthdate"},
+		// no generator produces values of the declared type or format
+		{`{"type": "integer", "title": "price"}`, ""},
+		{`{"type": "string", "format": "uuid", "title": "customerEmail"}`, ""},
+		{`{"type": "string", "format": "date", "title": "userName"}`, ""},
+		{`{"type": "integer", "title": "customerEmail"}`, ""},
+	} {
+		schema := testSchema(t, toPin.js)
+		decision := newFuzzyRulerFor(schema).Decide()
+		if toPin.expected == "" {
+			assert.Nil(t, decision, "%s: %v", toPin.js, decision)
+			continue
+		}
+		if assert.NotNil(t, decision, toPin.js) {
+			assert.Equal(t, toPin.expected, decision.Name(), toPin.js)
+		}
+	}
+
+	// fuzzy matches prevail over the type and format of the descriptor
+	gen := Generator{Language: "en", Seed: 1}
+	for i := 0; i < 20; i++ {
+		res, err := gen.GenParameter("", &spec.Parameter{
+			ParamProps:   spec.ParamProps{Name: "unitPrice", In: "query"},
+			SimpleSchema: spec.SimpleSchema{Type: "number"},
+		})
+		require.NoError(t, err)
+		price, ok := res.(float64)
+		require.True(t, ok, "expected a float64, got %T", res)
+		assert.True(t, price >= StubsDefaultMinAmount && price <= StubsDefaultMaxAmount, "price: %v", price)
+		assert.InDelta(t, math.Round(price*100), price*100, 1e-6, "price: %v", price)
+
+		res, err = gen.GenSchema("", testSchema(t, `{"type": "string", "format": "date", "title": "birthday"}`))
+		require.NoError(t, err)
+		birthdate, err := time.Parse(strfmt.RFC3339FullDate, res.(string))
+		require.NoError(t, err)
+		assert.True(t, birthdate.Before(seededReference.AddDate(-StubsDefaultMinAge, 0, 0)), "birthdate: %v", birthdate)
+		assert.True(t, birthdate.After(seededReference.AddDate(-StubsDefaultMaxAge, 0, 0)), "birthdate: %v", birthdate)
+
+		res, err = gen.GenHeader("X-Updated-At", &spec.Header{
+			HeaderProps:  spec.HeaderProps{Description: "The timestamp of the last update"},
+			SimpleSchema: spec.SimpleSchema{Type: "string", Format: "date-time"},
+		})
+		require.NoError(t, err)
+		updated, err := time.Parse(time.RFC3339, res.(string))
+		require.NoError(t, err)
+		assert.False(t, updated.After(seededReference), "updated: %v", updated)
+		assert.True(t, updated.After(seededReference.AddDate(0, 0, -StubsDefaultRecentDays-1)), "updated: %v", updated)
+
+		// ages are typed after the format of the descriptor
+		for format, expected := range map[string]interface{}{"int32": int32(0), "uint32": uint32(0), "int64": int64(0), "": int64(0)} {
+			res, err = gen.GenParameter("", &spec.Parameter{
+				ParamProps:   spec.ParamProps{Name: "customerAge", In: "query"},
+				SimpleSchema: spec.SimpleSchema{Type: "integer", Format: format},
+			})
+			require.NoError(t, err)
+			assert.IsType(t, expected, res, format)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package stubs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// formatGenerators maps string formats to the generator producing values valid for the format,
+// whenever the name of the format does not designate such a generator.
+//
+// Formats are keyed by their name in lower case and without dashes, as normalized by strfmt.
+var formatGenerators = map[string]string{
+	"binary":          "base64",
+	"bsonobjectid":    "bsonobjectid",
+	"byte":            "base64",
+	"cidr":            "cidr",
+	"country":         "country-code",
+	"creditcard":      "credit-card",
+	"currency":        "currency-code",
+	"duration":        "duration",
+	"durationhuman":   "duration",
+	"durationiso8601": "iso8601-duration",
+	"mac":             "mac-address",
+	"password":        "password",
+	"ssn":             "ssn",
+	"ulid":            "ulid",
+	"uri":             "uri",
+	"uuid7":           "uuid7",
+}
+
+// generatorForFormat yields the name of the generator for a string format, if any
+func generatorForFormat(format string) (string, bool) {
+	name, found := formatGenerators[strings.ReplaceAll(strings.ToLower(format), "-", "")]
+	return name, found
+}
+
+var (
+	// countryCodes is a sample of ISO 3166-1 alpha-2 country codes
+	countryCodes = []string{"AU", "BE", "BR", "CA", "CH", "CN", "DE", "ES", "FR", "GB", "IN", "IT", "JP", "NL", "SE", "US"}
+
+	// currencyCodes is a sample of ISO 4217 currency codes
+	currencyCodes = []string{"AUD", "BRL", "CAD", "CHF", "CNY", "EUR", "GBP", "INR", "JPY", "SEK", "USD"}
+)
+
+const (
+	// maxBase64Bytes is the largest number of random bytes encoded as a base64 string
+	maxBase64Bytes = 48
+	// maxDurationHours is the largest number of hours in generated durations
+	maxDurationHours = 1000
+)
+
+// pick returns a value generator which chooses randomly among a list of values
+func (g *generators) pick(values []string) ValueGenerator {
+	return func(opts GeneratorOpts) (interface{}, error) {
+		return values[g.entropy.IntN(len(values))], nil
+	}
+}
+
+// genBase64 generates some random bytes, encoded in standard base64
+func (g *generators) genBase64(opts GeneratorOpts) (interface{}, error) {
+	b := make([]byte, g.entropy.IntN(maxBase64Bytes)+1)
+	for i := range b {
+		b[i] = byte(g.entropy.IntN(256))
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// genCIDR generates an IPv4 or IPv6 network in CIDR notation
+func (g *generators) genCIDR(opts GeneratorOpts) (interface{}, error) {
+	if g.entropy.Bool() {
+		return fmt.Sprintf("%d.%d.%d.%d/%d",
+			g.entropy.IntN(256), g.entropy.IntN(256), g.entropy.IntN(256), g.entropy.IntN(256), g.entropy.IntN(33)), nil
+	}
+	groups := make([]string, 8)
+	for i := range groups {
+		groups[i] = strconv.FormatInt(int64(g.entropy.IntN(1<<16)), 16)
+	}
+	return fmt.Sprintf("%s/%d", strings.Join(groups, ":"), g.entropy.IntN(129)), nil
+}
+
+// genCreditCard generates a VISA card number, with a valid Luhn check digit
+func (g *generators) genCreditCard(opts GeneratorOpts) (interface{}, error) {
+	digits := make([]int, 16)
+	digits[0] = 4
+	for i := 1; i < len(digits)-1; i++ {
+		digits[i] = g.entropy.IntN(10)
+	}
+	digits[len(digits)-1] = luhnCheckDigit(digits[:len(digits)-1])
+	return joinDigits(digits), nil
+}
+
+// luhnCheckDigit computes the digit to append to a number for its Luhn checksum to be valid
+func luhnCheckDigit(digits []int) int {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// genISBN10 generates an ISBN-10 with a valid check digit
+func (g *generators) genISBN10(opts GeneratorOpts) (interface{}, error) {
+	digits := make([]int, 9)
+	checksum := 0
+	for i := range digits {
+		digits[i] = g.entropy.IntN(10)
+		checksum += (i + 1) * digits[i]
+	}
+	check := checksum % 11
+	if check == 10 {
+		return joinDigits(digits) + "X", nil
+	}
+	return joinDigits(append(digits, check)), nil
+}
+
+// genISBN13 generates an ISBN-13 in the 978 prefix, with a valid check digit
+func (g *generators) genISBN13(opts GeneratorOpts) (interface{}, error) {
+	digits := []int{9, 7, 8}
+	for len(digits) < 12 {
+		digits = append(digits, g.entropy.IntN(10))
+	}
+	checksum := 0
+	for i, d := range digits {
+		if i%2 == 1 {
+			d *= 3
+		}
+		checksum += d
+	}
+	return joinDigits(append(digits, (10-checksum%10)%10)), nil
+}
+
+// genISBN generates either an ISBN-10 or an ISBN-13
+func (g *generators) genISBN(opts GeneratorOpts) (interface{}, error) {
+	if g.entropy.Bool() {
+		return g.genISBN10(opts)
+	}
+	return g.genISBN13(opts)
+}
+
+func joinDigits(digits []int) string {
+	var b strings.Builder
+	for _, d := range digits {
+		b.WriteByte(byte('0' + d))
+	}
+	return b.String()
+}
+
+// genURI generates an absolute URI, with a path
+func (g *generators) genURI(opts GeneratorOpts) (interface{}, error) {
+	return fmt.Sprintf("https://%s/%s", g.faker.DomainName(), strings.Join(g.faker.Words(g.entropy.IntN(3)+1, false), "/")), nil
+}
+
+// genISO8601Duration generates a duration in ISO 8601 notation, e.g. P3DT4H12M5S
+func (g *generators) genISO8601Duration(opts GeneratorOpts) (interface{}, error) {
+	return fmt.Sprintf("P%dDT%dH%dM%dS", g.entropy.IntN(365), g.entropy.IntN(24), g.entropy.IntN(60), g.entropy.IntN(60)), nil
+}
@@ -0,0 +1,268 @@
+package stubs
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// fuzzyTitleWeight is the weight of matches found in the title (or name) of a descriptor
+	fuzzyTitleWeight = 1.0
+	// fuzzyDescriptionWeight is the weight of matches found in the description of a descriptor
+	fuzzyDescriptionWeight = 0.5
+
+	// exactTokenWeight is the weight of an alias matching whole tokens of a text, e.g. "zip-code" in "billingZipCode"
+	exactTokenWeight = 1.0
+	// substringWeight is the weight of an alias found at the start or the end of a token, e.g. "email" in "customeremail"
+	substringWeight = 0.5
+	// headTokenBonus is the bonus for matches on the last token, which is usually the head noun, e.g. "date" in "lastLoginDate"
+	headTokenBonus = 1.25
+	// letterWeight rewards longer, more specific aliases
+	letterWeight = 0.1
+
+	// minFuzzySubstringLength is the minimum length of an alias found inside a token: shorter aliases, such as "ip",
+	// are found in too many words
+	minFuzzySubstringLength = 4
+	// minFuzzyConfidence is the minimum score for the fuzzy ruler to take a decision
+	minFuzzyConfidence = 0.8
+)
+
+// unfuzzyGenerators are the generators never elected by fuzzy matching.
+//
+// Their aliases are common words, and the type ruler already elects them when nothing more specific applies.
+var unfuzzyGenerators = map[string]bool{
+	"adjective":  true,
+	"array":      true,
+	"bool":       true,
+	"characters": true,
+	"double":     true,
+	"enum":       true,
+	"float":      true,
+	"int32":      true,
+	"int64":      true,
+	"noun":       true,
+	"number":     true,
+	"object":     true,
+	"paragraph":  true,
+	"paragraphs": true,
+	"pattern":    true,
+	"sentence":   true,
+	"sentences":  true,
+	"uint32":     true,
+	"uint64":     true,
+	"word":       true,
+	"words":      true,
+}
+
+// fuzzyTypes are the types of descriptors for which a generator may be inferred by fuzzy matching
+var fuzzyTypes = map[string]bool{
+	"integer": true,
+	"number":  true,
+	"string":  true,
+}
+
+// generatorOutput describes the type and format of the values produced by a generator
+type generatorOutput struct {
+	Type   string
+	Format string
+}
+
+// generatorOutputs describes the values produced by generators, whenever these are not strings without a format
+var generatorOutputs = map[string]generatorOutput{
+	"age":              {Type: "integer"},
+	"amount":           {Type: "number"},
+	"birthdate":        {Type: "string", Format: "date"},
+	"date":             {Type: "string", Format: "date"},
+	"datetime":         {Type: "string", Format: "date-time"},
+	"duration":         {Type: "string", Format: "duration"},
+	"iso8601-duration": {Type: "string", Format: "duration-iso8601"},
+	"latitude":         {Type: "number"},
+	"longitude":        {Type: "number"},
+	"recent-datetime":  {Type: "string", Format: "date-time"},
+	"small-amount":     {Type: "number"},
+}
+
+// numericFormats are formats which do not convey the meaning of a number
+var numericFormats = map[string]bool{
+	"double": true,
+	"float":  true,
+	"int32":  true,
+	"int64":  true,
+	"uint32": true,
+	"uint64": true,
+}
+
+// fuzzyTypeOf yields the type of a descriptor suitable for fuzzy matching, if any
+func fuzzyTypeOf(types ...string) (string, bool) {
+	for _, t := range types {
+		if fuzzyTypes[t] {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// compatibleGenerator tells if a generator produces values of some type and format.
+//
+// Integer values are compatible with numbers. Strings without a format may be produced by any generator of strings,
+// whereas strings with a format may only be produced by generators for this format.
+func compatibleGenerator(name, typ, format string) bool {
+	output, found := generatorOutputs[name]
+	if !found {
+		output = generatorOutput{Type: "string"}
+	}
+	if typ == "" {
+		typ = "string"
+	}
+	switch {
+	case typ == "number" && output.Type == "integer":
+		return true
+	case output.Type != typ:
+		return false
+	case typ != "string" || format == "" || numericFormats[format]:
+		return true
+	default:
+		return normalizeFormat(output.Format) == normalizeFormat(format)
+	}
+}
+
+func normalizeFormat(format string) string {
+	return strings.ReplaceAll(strings.ToLower(format), "-", "")
+}
+
+// tokenize splits a text into lower case tokens, at blanks, punctuation and camelCase boundaries.
+//
+// Example:
+//
+//	tokenize("customerEmailAddress, HTTPServer_url") == []string{"customer", "email", "address", "http", "server", "url"}
+func tokenize(text string) []string {
+	runes := []rune(text)
+	tokens := make([]string, 0, 10)
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, strings.ToLower(string(runes[start:end])))
+		}
+		start = -1
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush(i)
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(runes))
+	return tokens
+}
+
+// scoreAgainstProposals computes the similarity score for a text against the proposed aliased keys.
+//
+// An alias matches whole tokens of the text (e.g. "zip-code" or "zipcode" in "billingZipCode"), or is found
+// at the start or the end of a token. Matches are weighted by their kind, the length of the alias and their position.
+// The score of a generator is the best score among its aliases.
+func scoreAgainstProposals(text string, proposals map[string]string) map[string]float64 {
+	score := make(map[string]float64, 10)
+	tokens := tokenize(text)
+	if len(tokens) == 0 {
+		return score
+	}
+	for alias, generator := range proposals {
+		if unfuzzyGenerators[generator] {
+			continue
+		}
+		if s := scoreAlias(alias, tokens); s > score[generator] {
+			score[generator] = s
+		}
+	}
+	return score
+}
+
+// scoreAlias computes the best score of an alias against the tokens of a text
+func scoreAlias(alias string, tokens []string) float64 {
+	compact := strings.Join(tokenize(alias), "")
+	if compact == "" {
+		return 0
+	}
+	specificity := 1 + letterWeight*float64(len(compact))
+	last := len(tokens) - 1
+
+	best := 0.0
+	for i := range tokens {
+		// whole tokens, possibly several joined together
+		joined := ""
+		for j := i; j <= last && len(joined) < len(compact); j++ {
+			joined += tokens[j]
+			if joined != compact {
+				continue
+			}
+			s := exactTokenWeight * specificity
+			if j == last {
+				s *= headTokenBonus
+			}
+			if s > best {
+				best = s
+			}
+		}
+
+		// start or end of a token
+		token := tokens[i]
+		if len(compact) < minFuzzySubstringLength || len(token) <= len(compact) ||
+			!(strings.HasPrefix(token, compact) || strings.HasSuffix(token, compact)) {
+			continue
+		}
+		s := substringWeight * specificity
+		if i == last {
+			s *= headTokenBonus
+		}
+		if s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// weighScores applies a weight to the scores computed for a text
+func weighScores(scores map[string]float64, weight float64) map[string]float64 {
+	for k := range scores {
+		scores[k] *= weight
+	}
+	return scores
+}
+
+// mergeAndElectProposal elects the best proposal from a slice of scoring maps.
+//
+// Scores are summed over all maps. Ties are broken in favor of the first key in alphabetical order,
+// so the election is deterministic.
+func mergeAndElectProposal(scores ...map[string]float64) (string, float64) {
+	var merger = make(map[string]float64, 10)
+	for _, scoringMap := range scores {
+		for k, score := range scoringMap {
+			merger[k] += score
+		}
+	}
+	keys := make([]string, 0, len(merger))
+	for k := range merger {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var key string
+	best := float64(0)
+	for _, k := range keys {
+		if s := merger[k]; s > best {
+			key, best = k, s
+		}
+	}
+	return key, best
+}
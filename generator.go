@@ -1,17 +1,56 @@
 package stubs
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"sync"
+	"time"
 
+	"github.com/go-openapi/loads"
 	"github.com/go-openapi/spec"
 )
 
 // Generator generates a stub for a descriptor.
 // A descriptor can either be a parameter, response header or json schema
+//
+// A Generator is safe for concurrent use, provided its exported fields are not modified once in use.
+// With a fixed seed, concurrent calls draw from the same source of entropy, in an unpredictable order:
+// use one generator per goroutine to obtain reproducible values.
 type Generator struct {
 	Language string
-	// Args represents general settings for the generator. These may be overriden by local x-datagen extensions.
-	//Args genTag
+	// Spec is the root document used to resolve $ref. It may be left nil when descriptors do not use $ref.
+	Spec *spec.Swagger
+	// MaxDepth is the maximum number of times a recursive $ref is followed. Defaults to StubsDefaultMaxDepth.
+	MaxDepth int
+	// Args represents general settings for the generator, with the same structure as the args of the x-datagen extension.
+	// These may be overriden by local x-datagen extensions.
+	//
+	// At the moment, only settings about sets of samples are supported (see GenerateSamples).
+	Args map[string]interface{}
+	// Seed is the seed of the source of entropy used for all generated values: a fixed seed yields the same
+	// values across runs. When 0, the generator is seeded from the current time.
+	//
+	// With a fixed seed, dates are generated relative to a fixed reference date rather than the current date.
+	Seed int64
+	// Verify checks every generated value against the descriptor with go-openapi/validate.
+	// Values which fail verification are generated again a few times, before giving up with ErrNoValid.
+	//
+	// Invalid values are likewise checked to actually fail the targeted validations.
+	Verify bool
+
+	mx     sync.Mutex
+	rnd    *rand.Rand
+	idle   map[string][]*generators  // generators available for reuse, by language
+	custom map[string]ValueGenerator // custom value generators, by name and alias (see RegisterGenerator)
+}
+
+// seededReference is the reference date for generated dates when the seed of the generator is fixed
+var seededReference = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// GeneratorForDocument builds a generator which resolves $ref against a loaded spec document
+func GeneratorForDocument(doc *loads.Document) *Generator {
+	return &Generator{Spec: doc.Spec()}
 }
 
 //TODO: replace key by unstructured hint
@@ -42,85 +81,174 @@ func (s *Generator) Generate(key string, descriptor interface{}) (interface{}, e
 
 // GenResponse generates a random value for a response
 func (s *Generator) GenResponse(key string, response *spec.Response) (interface{}, error) {
-	debugLog("generation for response: %q", response.Description)
-	// TODO: push downstream description and code (?) to help fuzzying
-	return s.GenSchema(key, response.Schema)
+	return s.gen(key, response)
 }
 
 // GenParameter generates a random value for a parameter
 func (s *Generator) GenParameter(key string, param *spec.Parameter) (interface{}, error) {
-	debugLog("generation for parameter: %s", param.Name)
-	generator, err := newGenerator(s.Language)
+	return s.gen(key, param)
+}
+
+// GenHeader generates a random value for a header
+func (s *Generator) GenHeader(key string, header *spec.Header) (interface{}, error) {
+	return s.gen(key, header)
+}
+
+// GenSchema generates a random value for a schema
+func (s *Generator) GenSchema(key string, schema *spec.Schema) (interface{}, error) {
+	return s.gen(key, schema)
+}
+
+// gen generates a random value for any supported descriptor
+func (s *Generator) gen(key string, descriptor interface{}) (interface{}, error) {
+	generator, err := s.acquire()
 	if err != nil {
 		return nil, err
 	}
+	defer s.release(generator)
 
-	if param.Schema != nil {
-		// TODO: push downstream name and description to help fuzzying
-		return s.GenSchema(key, param.Schema)
-	}
-	debugLog("resolving generation options for parameter: %s, type: %s, format: %s, isSchema: %t", param.Name, param.Type, param.Format, param.Schema != nil)
-	gopts, err := paramGenOpts(key, param)
-	if err != nil {
+	gopts, err := s.genOptsFor(generator, key, descriptor)
+	if err != nil || gopts == nil {
 		return nil, err
 	}
 
-	// infer options
-	gopts.Infer()
-
-	//if args.WithEdgeCase.Enabled && (len(args.WithEdgeCase.Args)==0 || args.WithEdgeCase.Args.Contains("standard")) {
-	// Standard edge cases are boundary values
-	// Edge cases managed at a higher level
-	//}
-	debugLog("generation options determined for parameter: %#v", gopts)
-
-	// TODO : slices
-	datagen, found := generator.For(gopts)
-	if !found {
-		return nil, fmt.Errorf("no generator found for parameter [%s]", param.Name)
+	// values with a declared invalid mode are always generated, even when optional, so the value fails
+	// all the declared validations
+	overrides := declaredModes(gopts)
+	var expected StubMode
+	for _, mode := range overrides {
+		expected |= mode
 	}
-
-	return datagen(gopts)
+	value, err := s.genSample(generator, key, descriptor, gopts, overrides, nil, expected)
+	if errors.Is(err, errNotApplied) {
+		return nil, fmt.Errorf("%w: %v", ErrNoInvalid, err)
+	}
+	return value, err
 }
 
-// GenHeader generates a random value for a header
-func (s *Generator) GenHeader(key string, header *spec.Header) (interface{}, error) {
-	generator, err := newGenerator(s.Language)
-	if err != nil {
-		return nil, err
+// acquire yields generators for the language of the generator, reusing idle generators when available.
+//
+// Generators are costly to build, and hold the state of the generation in progress: they are never shared
+// by concurrent calls, and must be released once done.
+//
+// New generators are seeded from the source of entropy of the generator: successive generators get different seeds,
+// which are reproducible when the seed is fixed.
+func (s *Generator) acquire() (*generators, error) {
+	s.mx.Lock()
+	if idle := s.idle[s.Language]; len(idle) > 0 {
+		generator := idle[len(idle)-1]
+		s.idle[s.Language] = idle[:len(idle)-1]
+		generator.custom = s.custom
+		s.mx.Unlock()
+		return generator, nil
 	}
+	seed, custom := s.nextSeed(), s.custom
+	s.mx.Unlock()
 
-	gopts, err := headerGenOpts(key, header)
+	generator, err := newGenerator(s.Language, seed)
 	if err != nil {
 		return nil, err
 	}
-
-	gopts.Infer()
-
-	datagen, found := generator.For(gopts)
-	if !found {
-		return nil, fmt.Errorf("no generator found for header [%s]", key)
+	generator.custom = custom
+	if s.Seed != 0 {
+		generator.now = func() time.Time { return seededReference }
 	}
+	return generator, nil
+}
 
-	return datagen(gopts)
+// release makes generators available for reuse
+func (s *Generator) release(generator *generators) {
+	generator.path, generator.overrides, generator.injections, generator.visited = "", nil, nil, nil
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.idle == nil {
+		s.idle = make(map[string][]*generators)
+	}
+	s.idle[s.Language] = append(s.idle[s.Language], generator)
 }
 
-// GenSchema generates a random value for a schema
-func (s *Generator) GenSchema(key string, schema *spec.Schema) (interface{}, error) {
-	generator, err := newGenerator(s.Language)
-	if err != nil {
-		return nil, err
+// nextSeed draws a seed for new generators. It must be called with the lock held.
+func (s *Generator) nextSeed() int64 {
+	if s.rnd == nil {
+		seed := s.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		s.rnd = rand.New(rand.NewSource(seed))
 	}
+	return s.rnd.Int63()
+}
 
-	gopts, err := schemaGenOpts(key, true, schema)
-	if err != nil {
-		return nil, err
+// genOptsFor builds the generation options for a descriptor.
+//
+// It returns nil options when there is nothing to generate, e.g. for a response without a schema.
+func (s *Generator) genOptsFor(generator *generators, key string, descriptor interface{}) (GeneratorOpts, error) {
+	switch desc := descriptor.(type) {
+	case *spec.Parameter:
+		param, err := s.resolveParameter(desc)
+		if err != nil {
+			return nil, err
+		}
+		debugLog("generation for parameter: %s", param.Name)
+		if param.Schema != nil {
+			// TODO: push downstream name and description to help fuzzying
+			return s.genOptsFor(generator, key, param.Schema)
+		}
+		debugLog("resolving generation options for parameter: %s, type: %s, format: %s", param.Name, param.Type, param.Format)
+		gopts, err := paramGenOpts(key, param)
+		if err != nil {
+			return nil, err
+		}
+		if gopts == nil {
+			return nil, fmt.Errorf("no type defined for parameter [%s]", param.Name)
+		}
+		return gopts, nil
+	case *spec.Header:
+		return headerGenOpts(key, desc)
+	case *spec.Schema:
+		return rootSchemaGenOpts(key, desc, newSchemaContext(s.Spec, s.MaxDepth, generator.entropy))
+	case *spec.Response:
+		response, err := s.resolveResponse(desc)
+		if err != nil {
+			return nil, err
+		}
+		debugLog("generation for response: %q", response.Description)
+		if response.Schema == nil {
+			return nil, nil
+		}
+		// TODO: push downstream description and code (?) to help fuzzying
+		return s.genOptsFor(generator, key, response.Schema)
+	case spec.Parameter:
+		return s.genOptsFor(generator, key, &desc)
+	case spec.Header:
+		return s.genOptsFor(generator, key, &desc)
+	case spec.Schema:
+		return s.genOptsFor(generator, key, &desc)
+	case spec.Response:
+		return s.genOptsFor(generator, key, &desc)
+	default:
+		return nil, fmt.Errorf("%T is unsupported for Generator", descriptor)
 	}
+}
 
-	datagen, found := generator.For(gopts)
-	if !found {
-		return nil, fmt.Errorf("no generator found for schema [%s]", key)
+// resolveParameter resolves a parameter defined by $ref
+func (s *Generator) resolveParameter(param *spec.Parameter) (*spec.Parameter, error) {
+	if param.Ref.String() == "" {
+		return param, nil
+	}
+	if s.Spec == nil {
+		return nil, fmt.Errorf("cannot resolve $ref %q without a root document", param.Ref.String())
 	}
+	return spec.ResolveParameter(s.Spec, param.Ref)
+}
 
-	return datagen(gopts)
+// resolveResponse resolves a response defined by $ref
+func (s *Generator) resolveResponse(response *spec.Response) (*spec.Response, error) {
+	if response.Ref.String() == "" {
+		return response, nil
+	}
+	if s.Spec == nil {
+		return nil, fmt.Errorf("cannot resolve $ref %q without a root document", response.Ref.String())
+	}
+	return spec.ResolveResponse(s.Spec, response.Ref)
 }
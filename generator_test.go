@@ -1 +1,1419 @@
 package stubs
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/bits"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSchema(t *testing.T, js string) *spec.Schema {
+	schema := new(spec.Schema)
+	require.NoError(t, json.Unmarshal([]byte(js), schema))
+	return schema
+}
+
+func TestGenerator_GenSchemaObject(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id", "name", "address"],
+		"properties": {
+			"id": {"type": "integer", "format": "int64"},
+			"name": {"type": "string"},
+			"birthdate": {"type": "string", "format": "date"},
+			"active": {"type": "boolean"},
+			"address": {
+				"type": "object",
+				"required": ["city"],
+				"properties": {
+					"city": {"type": "string"},
+					"zip": {"type": "string", "format": "uuid"}
+				}
+			}
+		}
+	}`)
+
+	gen := Generator{Language: "en"}
+	for i := 0; i < 20; i++ {
+		res, err := gen.GenSchema("customer", schema)
+		if assert.NoError(t, err) {
+			obj, ok := res.(map[string]interface{})
+			if assert.True(t, ok, "expected an object, got %T", res) {
+				assert.Contains(t, obj, "id")
+				assert.Contains(t, obj, "name")
+				assert.Contains(t, obj, "address")
+			}
+			assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default))
+		}
+	}
+}
+
+func TestGenerator_GenSchemaObjectInvalidRequired(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "integer"}
+		},
+		"x-datagen": {"mode": 2}
+	}`)
+
+	gen := Generator{Language: "en"}
+	res, err := gen.GenSchema("", schema)
+	if assert.NoError(t, err) {
+		assert.Error(t, validate.AgainstSchema(schema, res, strfmt.Default))
+	}
+}
+
+func TestGenerator_GenSchemaArray(t *testing.T) {
+	for _, js := range []string{
+		`{"type": "array", "items": {"type": "string"}, "minItems": 2, "maxItems": 4}`,
+		`{"type": "array", "items": {"type": "integer", "format": "int32"}, "minItems": 8, "uniqueItems": true}`,
+		`{"type": "array", "items": {"type": "array", "items": {"type": "boolean"}, "maxItems": 2}}`,
+		`{"type": "array", "items": [{"type": "string", "format": "date"}, {"type": "boolean"}], "additionalItems": false}`,
+		`{"type": "array", "items": {"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}}`,
+	} {
+		schema := testSchema(t, js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 10; i++ {
+			res, err := gen.GenSchema("", schema)
+			if assert.NoError(t, err, js) {
+				assert.IsType(t, []interface{}{}, res)
+				assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default), js)
+			}
+		}
+	}
+}
+
+func TestGenerator_GenSchemaArrayUnique(t *testing.T) {
+	gen := Generator{Language: "en"}
+
+	// there are not enough distinct booleans to satisfy this schema
+	schema := testSchema(t, `{"type": "array", "items": {"type": "boolean"}, "minItems": 3, "uniqueItems": true}`)
+	_, err := gen.GenSchema("", schema)
+	assert.Equal(t, ErrNoValid, err)
+
+	schema = testSchema(t, `{"type": "array", "items": {"type": "integer"}, "uniqueItems": true, "x-datagen": {"mode": 512}}`)
+	res, err := gen.GenSchema("", schema)
+	if assert.NoError(t, err) {
+		assert.Error(t, validate.AgainstSchema(schema, res, strfmt.Default))
+	}
+}
+
+func TestGenerator_GenParameterArray(t *testing.T) {
+	param := spec.QueryParam("tags").CollectionOf(
+		spec.NewItems().CollectionOf(spec.NewItems().Typed("string", "uuid"), "csv").
+			WithMaxItems(3),
+		"pipes",
+	).WithMinItems(1).WithMaxItems(5).UniqueValues()
+
+	gen := Generator{Language: "en"}
+	for i := 0; i < 10; i++ {
+		res, err := gen.GenParameter("", param)
+		if assert.NoError(t, err) {
+			tags, ok := res.([]interface{})
+			if assert.True(t, ok, "expected an array, got %T", res) {
+				assert.True(t, len(tags) >= 1 && len(tags) <= 5)
+				for _, tag := range tags {
+					assert.IsType(t, []interface{}{}, tag)
+					assert.True(t, len(tag.([]interface{})) <= 3)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerator_GenSchemaRef(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest3.yaml"))
+	require.NoError(t, err)
+
+	for _, maxDepth := range []int{0, 1, 5} {
+		gen := GeneratorForDocument(doc)
+		gen.MaxDepth = maxDepth
+		for _, def := range []string{"Tree", "LinkedList", "Node"} {
+			schema := spec.RefSchema("#/definitions/" + def)
+			for i := 0; i < 10; i++ {
+				res, err := gen.GenSchema(def, schema)
+				if assert.NoError(t, err) {
+					result := validate.NewSchemaValidator(schema, doc.Spec(), "", strfmt.Default).Validate(res)
+					assert.True(t, result.IsValid(), "%v", result.Errors)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerator_GenSchemaRefMaxDepth(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest3.yaml"))
+	require.NoError(t, err)
+
+	gen := GeneratorForDocument(doc)
+	gen.MaxDepth = 2
+	for i := 0; i < 20; i++ {
+		res, err := gen.GenSchema("", spec.RefSchema("#/definitions/Node"))
+		if assert.NoError(t, err) {
+			depth := 0
+			for node, ok := res.(map[string]interface{}); ok; node, ok = node["next"].(map[string]interface{}) {
+				depth++
+			}
+			assert.True(t, depth <= gen.MaxDepth+1, "unexpected depth: %d", depth)
+		}
+	}
+
+	// no finite value may be generated for this schema
+	root := &spec.Swagger{}
+	root.Definitions = spec.Definitions{
+		"Loop": *testSchema(t, `{"type": "object", "required": ["next"], "properties": {"next": {"$ref": "#/definitions/Loop"}}}`),
+	}
+	gen = &Generator{Spec: root}
+	_, err = gen.GenSchema("", spec.RefSchema("#/definitions/Loop"))
+	assert.Error(t, err)
+
+	// $ref cannot be resolved without a root document
+	gen = &Generator{}
+	_, err = gen.GenSchema("", spec.RefSchema("#/definitions/Node"))
+	assert.Error(t, err)
+}
+
+func TestGenerator_GenParameterRef(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest3.yaml"))
+	require.NoError(t, err)
+
+	gen := GeneratorForDocument(doc)
+	param := spec.Parameter{Refable: spec.Refable{Ref: spec.MustCreateRef("#/parameters/limit")}}
+	res, err := gen.GenParameter("", &param)
+	if assert.NoError(t, err) {
+		assert.IsType(t, int32(0), res)
+	}
+}
+
+func TestGenerator_GenSchemaAllOf(t *testing.T) {
+	root := &spec.Swagger{}
+	root.Definitions = spec.Definitions{
+		"Pet": *testSchema(t, `{
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string"},
+				"weight": {"type": "number", "minimum": 0, "maximum": 100}
+			}
+		}`),
+	}
+	gen := &Generator{Spec: root}
+
+	for _, js := range []string{
+		`{"allOf": [{"$ref": "#/definitions/Pet"}, {"required": ["age"], "properties": {"age": {"type": "number", "minimum": 1, "maximum": 30}}}]}`,
+		`{"allOf": [{"$ref": "#/definitions/Pet"}, {"properties": {"weight": {"type": "number", "minimum": 50, "exclusiveMinimum": true}}}]}`,
+		`{"allOf": [{"type": "number", "minimum": 12, "multipleOf": 2}, {"maximum": 20, "multipleOf": 3}]}`,
+		`{"allOf": [{"type": "number", "minimum": 0, "maximum": 10, "multipleOf": 0.3}, {"multipleOf": 0.7}]}`,
+		`{"allOf": [{"type": "number", "minimum": 0, "maximum": 10, "multipleOf": 0.25}, {"multipleOf": 1.5}]}`,
+		`{"allOf": [{"type": "string", "pattern": "^[0-9]{3}$"}, {"pattern": "^[0-4]"}]}`,
+		`{"allOf": [{"type": "array", "items": {"type": "string"}, "maxItems": 5}, {"minItems": 2}]}`,
+	} {
+		schema := testSchema(t, js)
+		for i := 0; i < 10; i++ {
+			res, err := gen.GenSchema("", schema)
+			if assert.NoError(t, err, js) {
+				result := validate.NewSchemaValidator(schema, root, "", strfmt.Default).Validate(res)
+				assert.True(t, result.IsValid(), "%s: %v", js, result.Errors)
+			}
+		}
+	}
+}
+
+func TestGenerator_GenSchemaAllOfSiblingRefs(t *testing.T) {
+	// members sharing a $ref are not a recursion
+	root := &spec.Swagger{}
+	root.Definitions = spec.Definitions{
+		"Named":    *testSchema(t, `{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}`),
+		"Pet":      *testSchema(t, `{"allOf": [{"$ref": "#/definitions/Named"}, {"properties": {"weight": {"type": "number"}}}]}`),
+		"Licensed": *testSchema(t, `{"allOf": [{"$ref": "#/definitions/Named"}, {"properties": {"license": {"type": "string"}}}]}`),
+		"Insured":  *testSchema(t, `{"allOf": [{"$ref": "#/definitions/Named"}, {"properties": {"policy": {"type": "string"}}}]}`),
+	}
+	gen := &Generator{Spec: root, MaxDepth: 1}
+	schema := testSchema(t, `{"allOf": [{"$ref": "#/definitions/Pet"}, {"$ref": "#/definitions/Licensed"}, {"$ref": "#/definitions/Insured"}]}`)
+
+	res, err := gen.GenSchema("", schema)
+	if assert.NoError(t, err) {
+		result := validate.NewSchemaValidator(schema, root, "", strfmt.Default).Validate(res)
+		assert.True(t, result.IsValid(), "%v", result.Errors)
+	}
+}
+
+func TestGenerator_GenSchemaAllOfContradictory(t *testing.T) {
+	gen := &Generator{}
+	for _, js := range []string{
+		`{"allOf": [{"type": "string"}, {"type": "integer"}]}`,
+		`{"allOf": [{"type": "number", "minimum": 10}, {"maximum": 5}]}`,
+		`{"allOf": [{"type": "number", "minimum": 10}, {"maximum": 10, "exclusiveMaximum": true}]}`,
+		`{"allOf": [{"type": "string", "minLength": 10}, {"maxLength": 5}]}`,
+		`{"allOf": [{"type": "string", "enum": ["a", "b"]}, {"enum": ["c"]}]}`,
+	} {
+		_, err := gen.GenSchema("", testSchema(t, js))
+		assert.Error(t, err, js)
+	}
+}
+
+func TestGenerator_GenSchemaDiscriminator(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+	gen := GeneratorForDocument(doc)
+
+	definitions := map[string]string{"Cat": "Cat", "doggy": "Dog", "Puppy": "Puppy"}
+	picked := make(map[string]bool, len(definitions))
+	for i := 0; i < 60; i++ {
+		res, err := gen.GenSchema("", spec.RefSchema("#/definitions/Pet"))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		pet := res.(map[string]interface{})
+		petType, _ := pet["petType"].(string)
+		definition, ok := definitions[petType]
+		if assert.True(t, ok, "unexpected discriminator value: %q", petType) {
+			picked[petType] = true
+			schema := spec.RefSchema("#/definitions/" + definition)
+			result := validate.NewSchemaValidator(schema, doc.Spec(), "", strfmt.Default).Validate(res)
+			assert.True(t, result.IsValid(), "%v", result.Errors)
+		}
+	}
+	assert.Len(t, picked, len(definitions))
+
+	// a subtype generated directly gets its own discriminator value
+	res, err := gen.GenSchema("", spec.RefSchema("#/definitions/Dog"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "doggy", res.(map[string]interface{})["petType"])
+	}
+
+	// weights exclude Poodle
+	for i := 0; i < 10; i++ {
+		res, err := gen.GenSchema("", spec.RefSchema("#/definitions/Kennel"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Bulldog", res.(map[string]interface{})["dog"].(map[string]interface{})["petType"])
+		}
+	}
+}
+
+func TestGenerator_GenSchemaDiscriminatorPinned(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+	gen := GeneratorForDocument(doc)
+
+	pet := doc.Spec().Definitions["Pet"]
+	pet.AddExtension(XdataGen, map[string]interface{}{"args": map[string]interface{}{"subtype": "Cat"}})
+	for i := 0; i < 10; i++ {
+		res, err := gen.GenSchema("Pet", &pet)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Cat", res.(map[string]interface{})["petType"])
+			assert.Contains(t, res, "huntingSkill")
+		}
+	}
+
+	pet.AddExtension(XdataGen, map[string]interface{}{"args": map[string]interface{}{"subtype": "Bulldog"}})
+	_, err = gen.GenSchema("Pet", &pet)
+	assert.Error(t, err)
+}
+
+func TestGenerator_GenSchemaDiscriminatorInline(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+	gen := GeneratorForDocument(doc)
+
+	// an inline property named like a definition does not stand for this definition
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["Pet"],
+		"properties": {
+			"Pet": {
+				"type": "object",
+				"discriminator": "kind",
+				"required": ["kind"],
+				"properties": {"kind": {"type": "string", "enum": ["inline"]}}
+			}
+		}
+	}`)
+	for i := 0; i < 10; i++ {
+		res, err := gen.GenSchema("", schema)
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[string]interface{}{"kind": "inline"}, res.(map[string]interface{})["Pet"])
+		}
+	}
+}
+
+func TestGenerator_GenSchemaAdditionalProperties(t *testing.T) {
+	gen := &Generator{}
+	for _, js := range []string{
+		`{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}, "additionalProperties": true, "maxProperties": 4}`,
+		`{"type": "object", "additionalProperties": {"type": "integer", "format": "int32"}, "minProperties": 3}`,
+		`{"type": "object", "additionalProperties": false, "minProperties": 2, "patternProperties": {
+			"^x-[a-z]+$": {"type": "string"},
+			"^[0-9]{3}$": {"type": "boolean"}
+		}}`,
+		`{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string"}}, "minProperties": 2, "maxProperties": 2}`,
+		`{"type": "object", "additionalProperties": {"type": "string", "format": "uuid"}, "x-datagen": {"args": {"length": 10}}}`,
+	} {
+		schema := testSchema(t, js)
+		for i := 0; i < 10; i++ {
+			res, err := gen.GenSchema("", schema)
+			if assert.NoError(t, err, js) {
+				assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default), js)
+				assert.True(t, len(res.(map[string]interface{})) <= 10)
+			}
+		}
+	}
+
+	// not enough properties may be generated
+	_, err := gen.GenSchema("", testSchema(t, `{"type": "object", "properties": {"a": {"type": "string"}}, "minProperties": 2}`))
+	assert.Equal(t, ErrNoValid, err)
+}
+
+func TestGenerator_GenSchemaInteger(t *testing.T) {
+	for _, js := range []string{
+		`{"type": "integer", "minimum": 10, "maximum": 12}`,
+		`{"type": "integer", "minimum": 10, "maximum": 12, "exclusiveMinimum": true, "exclusiveMaximum": true}`,
+		`{"type": "integer", "format": "int32", "minimum": -100, "maximum": 100, "multipleOf": 7}`,
+		`{"type": "integer", "format": "int32", "minimum": 1500000000}`,
+		`{"type": "integer", "format": "uint32", "maximum": 5}`,
+		`{"type": "integer", "format": "uint64", "multipleOf": 3}`,
+		`{"type": "integer", "minimum": 0, "maximum": 0}`,
+		`{"type": "integer", "x-datagen": {"args": {"min": 100, "max": 110, "multipleOf": 5}}}`,
+		`{"type": "integer", "minimum": 1000, "x-datagen": {"args": {"min": 100, "max": 110}}}`,
+	} {
+		schema := testSchema(t, js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("count", schema)
+			if assert.NoError(t, err, js) {
+				assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", js, res)
+			}
+		}
+	}
+
+	// a contradictory validation yields no value
+	gen := Generator{Language: "en"}
+	_, err := gen.GenSchema("", testSchema(t, `{"type": "integer", "minimum": 1, "maximum": 1, "exclusiveMaximum": true}`))
+	assert.Error(t, err)
+	_, err = gen.GenSchema("", testSchema(t, `{"type": "integer", "minimum": 1, "maximum": 2, "multipleOf": 3}`))
+	assert.Error(t, err)
+	_, err = gen.GenSchema("", testSchema(t, `{"type": "integer", "format": "int32", "minimum": 3000000000}`))
+	assert.Error(t, err)
+}
+
+func TestGenerator_GenSchemaIntegerInvalid(t *testing.T) {
+	for _, toPin := range []struct {
+		js      string
+		invalid bool
+	}{
+		{`{"type": "integer", "minimum": 10, "x-datagen": {"mode": 8}}`, true},
+		{`{"type": "integer", "minimum": 10, "exclusiveMinimum": true, "x-datagen": {"mode": 8}}`, true},
+		{`{"type": "integer", "maximum": 10, "x-datagen": {"mode": 4}}`, true},
+		{`{"type": "integer", "minimum": 0, "maximum": 100, "multipleOf": 10, "x-datagen": {"mode": 1024}}`, true},
+		{`{"type": "integer", "minimum": 10, "maximum": 20, "multipleOf": 3, "x-datagen": {"mode": 1032}}`, true},
+		{`{"type": "integer", "format": "uint32", "minimum": 0, "x-datagen": {"mode": 8}}`, false},
+		{`{"type": "integer", "multipleOf": 1, "x-datagen": {"mode": 1024}}`, false},
+		{`{"type": "integer", "x-datagen": {"mode": 4}}`, false},
+	} {
+		schema := testSchema(t, toPin.js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("count", schema)
+			if !toPin.invalid {
+				assert.Error(t, err, toPin.js)
+				continue
+			}
+			if assert.NoError(t, err, toPin.js) {
+				assert.Error(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", toPin.js, res)
+			}
+		}
+	}
+}
+
+func TestGenerator_GenSchemaStringLength(t *testing.T) {
+	for _, js := range []string{
+		`{"type": "string", "maxLength": 8}`,
+		`{"type": "string", "minLength": 200}`,
+		`{"type": "string", "minLength": 5, "maxLength": 5}`,
+		`{"type": "string", "minLength": 1, "maxLength": 3, "x-datagen": {"name": "characters"}}`,
+		`{"type": "string", "format": "uuid", "minLength": 36, "maxLength": 36}`,
+		`{"type": "string", "pattern": "^[a-z]{10}[0-9]?$", "minLength": 11}`,
+	} {
+		schema := testSchema(t, js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("", schema)
+			if assert.NoError(t, err, js) {
+				assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", js, res)
+			}
+		}
+	}
+
+	// a formatted value is regenerated, not truncated
+	gen := Generator{Language: "en"}
+	_, err := gen.GenSchema("", testSchema(t, `{"type": "string", "format": "uuid", "maxLength": 8}`))
+	assert.Error(t, err)
+}
+
+func TestGenerator_GenSchemaStringLengthInvalid(t *testing.T) {
+	for _, toPin := range []struct {
+		js      string
+		invalid bool
+	}{
+		{`{"type": "string", "maxLength": 8, "x-datagen": {"mode": 16}}`, true},
+		{`{"type": "string", "minLength": 100, "x-datagen": {"mode": 32}}`, true},
+		{`{"type": "string", "format": "uuid", "maxLength": 36, "x-datagen": {"mode": 16}}`, true},
+		{`{"type": "string", "minLength": 0, "x-datagen": {"mode": 32}}`, false},
+		{`{"type": "string", "x-datagen": {"mode": 16}}`, false},
+	} {
+		schema := testSchema(t, toPin.js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("", schema)
+			if !toPin.invalid {
+				assert.Error(t, err, toPin.js)
+				continue
+			}
+			if assert.NoError(t, err, toPin.js) {
+				assert.Error(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", toPin.js, res)
+			}
+		}
+	}
+}
+
+func TestGenerator_GenSchemaInvalidReused(t *testing.T) {
+	// the mode of options is picked again for each value, when options are reused
+	gen := Generator{Language: "en"}
+	schema := testSchema(t, `{"type": "string", "minLength": 4, "maxLength": 8, "x-datagen": {"mode": 1}}`)
+	generator, err := gen.acquire()
+	require.NoError(t, err)
+	defer gen.release(generator)
+	gopts, err := gen.genOptsFor(generator, "", schema)
+	require.NoError(t, err)
+
+	var violations StubMode
+	for i := 0; i < 50; i++ {
+		res, err := generator.generate(gopts)
+		require.NoError(t, err)
+		assert.Equal(t, Invalid, gopts.Mode())
+		require.IsType(t, "", res)
+		switch length := len([]rune(res.(string))); {
+		case length < 4:
+			violations |= InvalidMinLength
+		case length > 8:
+			violations |= InvalidMaxLength
+		}
+	}
+	assert.Equal(t, InvalidMinLength|InvalidMaxLength, violations)
+}
+
+func TestGenerator_GenSchemaEnum(t *testing.T) {
+	for _, toPin := range []struct {
+		js       string
+		expected []interface{}
+	}{
+		{`{"type": "string", "enum": ["a", "b", "c"]}`, []interface{}{"a", "b", "c"}},
+		{`{"type": "integer", "format": "int32", "enum": [1, 2, 3]}`, []interface{}{int32(1), int32(2), int32(3)}},
+		{`{"type": "integer", "enum": [10, 20]}`, []interface{}{int64(10), int64(20)}},
+		{`{"type": "number", "enum": [1.5, 2.5]}`, []interface{}{1.5, 2.5}},
+		{`{"type": "boolean", "enum": [true]}`, []interface{}{true}},
+		{`{"type": "string", "enum": ["a", "b", "c"], "x-datagen": {"args": {"enum": {"b": 1}}}}`, []interface{}{"b"}},
+		{`{"type": "integer", "enum": [1, 2, 3], "x-datagen": {"args": {"enum": {"1": 0, "3": 2}}}}`, []interface{}{int64(3)}},
+	} {
+		schema := testSchema(t, toPin.js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("", schema)
+			if assert.NoError(t, err, toPin.js) {
+				assert.Contains(t, toPin.expected, res, toPin.js)
+				assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", toPin.js, res)
+			}
+		}
+	}
+}
+
+func TestGenerator_GenSchemaEnumInvalid(t *testing.T) {
+	for _, toPin := range []struct {
+		js      string
+		invalid bool
+	}{
+		{`{"type": "string", "enum": ["a", "b", "c"], "x-datagen": {"mode": 2048}}`, true},
+		{`{"type": "string", "enum": [""], "x-datagen": {"mode": 2048}}`, true},
+		{`{"type": "integer", "format": "uint32", "enum": [0, 1, 2], "x-datagen": {"mode": 2048}}`, true},
+		{`{"type": "number", "enum": [1.5, 2.5], "x-datagen": {"mode": 2048}}`, true},
+		{`{"type": "boolean", "enum": [false], "x-datagen": {"mode": 2048}}`, true},
+		{`{"type": "boolean", "enum": [true, false], "x-datagen": {"mode": 2048}}`, false},
+	} {
+		schema := testSchema(t, toPin.js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("", schema)
+			if !toPin.invalid {
+				assert.Error(t, err, toPin.js)
+				continue
+			}
+			if assert.NoError(t, err, toPin.js) {
+				assert.Error(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", toPin.js, res)
+			}
+		}
+	}
+}
+
+func TestGenerator_GenParameterEnum(t *testing.T) {
+	param := spec.QueryParam("status").Typed("integer", "int64")
+	param.WithEnum(1, 2, 3)
+
+	gen := Generator{Language: "en", Spec: &spec.Swagger{}}
+	for i := 0; i < 20; i++ {
+		res, err := gen.GenParameter("status", param)
+		if assert.NoError(t, err) {
+			assert.Contains(t, []interface{}{int64(1), int64(2), int64(3)}, res)
+		}
+	}
+}
+
+func TestGenerator_GenSchemaTilting(t *testing.T) {
+	for _, toPin := range []struct {
+		js       string
+		expected interface{}
+	}{
+		{`{"type": "integer", "maximum": 10, "x-datagen": {"mode": 4}}`, int64(11)},
+		{`{"type": "integer", "maximum": 10, "exclusiveMaximum": true, "x-datagen": {"mode": 4}}`, int64(10)},
+		{`{"type": "integer", "maximum": 10, "multipleOf": 3, "x-datagen": {"mode": 4}}`, int64(12)},
+		{`{"type": "integer", "format": "int32", "minimum": 10, "x-datagen": {"mode": 8}}`, int32(9)},
+		{`{"type": "integer", "minimum": 10, "multipleOf": 3, "x-datagen": {"mode": 8}}`, int64(9)},
+		{`{"type": "number", "maximum": 1.5, "x-datagen": {"mode": 4}}`, math.Nextafter(1.5, 2)},
+		{`{"type": "number", "minimum": 1.5, "exclusiveMinimum": true, "x-datagen": {"mode": 8}}`, 1.5},
+	} {
+		schema := testSchema(t, toPin.js)
+		gen := Generator{Language: "en"}
+		res, err := gen.GenSchema("", schema)
+		if assert.NoError(t, err, toPin.js) {
+			assert.Equal(t, toPin.expected, res, toPin.js)
+		}
+	}
+
+	for _, toPin := range []struct {
+		js     string
+		length int
+	}{
+		{`{"type": "string", "maxLength": 5, "x-datagen": {"mode": 16}}`, 6},
+		{`{"type": "string", "minLength": 5, "x-datagen": {"mode": 32}}`, 4},
+		{`{"type": "array", "items": {"type": "integer"}, "maxItems": 3, "x-datagen": {"mode": 128}}`, 4},
+		{`{"type": "array", "items": {"type": "integer"}, "minItems": 3, "x-datagen": {"mode": 256}}`, 2},
+	} {
+		schema := testSchema(t, toPin.js)
+		gen := Generator{Language: "en"}
+		res, err := gen.GenSchema("", schema)
+		if assert.NoError(t, err, toPin.js) {
+			assert.Len(t, res, toPin.length, toPin.js)
+		}
+	}
+
+	for _, js := range []string{
+		`{"type": "string", "pattern": "^[a-z]{3}-[0-9]{2}$", "x-datagen": {"mode": 64}}`,
+		`{"type": "integer", "minimum": 0, "maximum": 100, "multipleOf": 10, "x-datagen": {"mode": 1028}}`,
+		`{"type": "number", "minimum": 0, "maximum": 100, "multipleOf": 0.5, "x-datagen": {"mode": 1024}}`,
+		`{"type": "array", "items": {"type": "integer"}, "uniqueItems": true, "maxItems": 3, "x-datagen": {"mode": 512}}`,
+		`{"type": "string", "maxLength": 10, "pattern": "^[a-z]{1,8}$", "x-datagen": {"mode": 1}}`,
+		`{"type": "integer", "maximum": 10, "x-datagen": {"args": {"withTilting": {"enabled": true}}}}`,
+		`{"type": "integer", "maximum": 10, "x-datagen": {"mode": 4, "args": {"skipTilting": true}}}`,
+	} {
+		schema := testSchema(t, js)
+		gen := Generator{Language: "en"}
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("", schema)
+			if assert.NoError(t, err, js) {
+				assert.Error(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", js, res)
+			}
+		}
+	}
+}
+
+func TestGenerator_GenValidationChecks(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1, "maximum": 1000},
+			"name": {"type": "string", "minLength": 2, "maxLength": 20},
+			"tags": {
+				"type": "array",
+				"maxItems": 3,
+				"uniqueItems": true,
+				"items": {"type": "string", "enum": ["red", "green", "blue", "yellow"]}
+			}
+		}
+	}`)
+	expected := []validationCheck{
+		{path: "", mode: InvalidRequired},
+		{path: "/id", mode: InvalidMaximum},
+		{path: "/id", mode: InvalidMinimum},
+		{path: "/name", mode: InvalidMaxLength},
+		{path: "/name", mode: InvalidMinLength},
+		{path: "/tags", mode: InvalidMaxItems},
+		{path: "/tags", mode: InvalidUniqueItems},
+		{path: "/tags/items", mode: InvalidEnum},
+	}
+
+	gen := Generator{Language: "en"}
+	samples, err := gen.GenValidationChecks("", schema, false, 0)
+	require.NoError(t, err)
+	require.Len(t, samples, len(expected))
+	for i, sample := range samples {
+		assert.False(t, sample.Valid)
+		assert.Equal(t, expected[i].mode, sample.Violations)
+		assert.Equal(t, OriginValidationCheck, sample.Origin)
+		assert.Error(t, validate.AgainstSchema(schema, sample.Value, strfmt.Default), "%d: %v", i, sample.Value)
+	}
+
+	samples, err = gen.GenValidationChecks("", schema, false, 3)
+	require.NoError(t, err)
+	assert.Len(t, samples, 3)
+
+	// all combinations, breadth-first
+	samples, err = gen.GenValidationChecks("", schema, true, 20)
+	require.NoError(t, err)
+	require.Len(t, samples, 20)
+	assert.True(t, samples[0].Valid)
+	assert.NoError(t, validate.AgainstSchema(schema, samples[0].Value, strfmt.Default))
+	for i, sample := range samples[1:] {
+		assert.False(t, sample.Valid)
+		assert.Error(t, validate.AgainstSchema(schema, sample.Value, strfmt.Default), "%d: %v", i, sample.Value)
+	}
+	for i := 1; i < len(samples); i++ {
+		assert.True(t, bits.OnesCount64(uint64(samples[i-1].Violations)) <= bits.OnesCount64(uint64(samples[i].Violations)))
+	}
+	assert.Equal(t, 2, bits.OnesCount64(uint64(samples[len(expected)+1].Violations)))
+}
+
+func TestGenerator_GenValidationChecksAll(t *testing.T) {
+	// minimum and maximum cannot be combined on the same value
+	param := spec.QueryParam("limit").Typed("integer", "int32")
+	param.WithMinimum(1, false).WithMaximum(100, false).WithMultipleOf(5)
+
+	gen := Generator{Language: "en"}
+	samples, err := gen.GenValidationChecks("limit", param, true, 0)
+	require.NoError(t, err)
+	violations := make([]StubMode, 0, len(samples))
+	for _, sample := range samples {
+		violations = append(violations, sample.Violations)
+	}
+	assert.Equal(t, []StubMode{
+		Valid,
+		InvalidMaximum,
+		InvalidMinimum,
+		InvalidMultipleOf,
+		InvalidMaximum | InvalidMultipleOf,
+		InvalidMinimum | InvalidMultipleOf,
+	}, violations)
+}
+
+func TestGenerator_GenValidationChecksEscapedPath(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["a/b"],
+		"properties": {
+			"a/b": {"type": "integer", "minimum": 1, "maximum": 10},
+			"a": {"type": "object", "properties": {"b": {"type": "integer", "minimum": 1, "maximum": 10}}}
+		}
+	}`)
+
+	gen := Generator{Language: "en"}
+	samples, err := gen.GenValidationChecks("escaped", schema, false, 0)
+	require.NoError(t, err)
+	for _, sample := range samples {
+		if sample.Violations != InvalidMaximum {
+			continue
+		}
+		// only one of the properties exceeds its maximum
+		obj, ok := sample.Value.(map[string]interface{})
+		require.True(t, ok)
+		result := validate.NewSchemaValidator(schema, nil, "", strfmt.Default).Validate(obj)
+		assert.Len(t, result.Errors, 1, "%v", obj)
+	}
+}
+
+func TestGenerator_GenValidationChecksDiscriminator(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+
+	// validations are checked on the subtype picked when listing them
+	for seed := int64(1); seed < 40; seed++ {
+		gen := GeneratorForDocument(doc)
+		gen.Seed = seed
+		samples, err := gen.GenValidationChecks("", spec.RefSchema("#/definitions/Pet"), false, 0)
+		require.NoError(t, err)
+		for _, sample := range samples {
+			assertPetViolations(t, doc, sample, "seed %d", seed)
+		}
+	}
+
+	// overrides at paths which are not generated are reported
+	gen := GeneratorForDocument(doc)
+	generator, err := gen.acquire()
+	require.NoError(t, err)
+	defer gen.release(generator)
+	cat := spec.RefSchema("#/definitions/Cat")
+	gopts, err := gen.genOptsFor(generator, "", cat)
+	require.NoError(t, err)
+	_, err = gen.genSample(generator, "", cat, gopts, map[string]StubMode{"/packSize": InvalidMinimum}, nil, InvalidMinimum)
+	assert.True(t, errors.Is(err, errNotApplied), "%v", err)
+}
+
+// assertPetViolations checks that a sample generated for the Pet definition of gentest4.yaml fails the validations
+// it is labelled with, against the subtype which has been picked
+func assertPetViolations(t *testing.T, doc *loads.Document, sample Sample, msgAndArgs ...interface{}) {
+	definitions := map[string]string{"Cat": "Cat", "doggy": "Dog", "Puppy": "Puppy"}
+	pet := sample.Value.(map[string]interface{})
+	petType, found := pet["petType"].(string)
+	if !found {
+		// the discriminator property may be omitted when failing required properties
+		assert.True(t, sample.Violations.Has(InvalidRequired), msgAndArgs...)
+		return
+	}
+	definition, ok := definitions[petType]
+	if !assert.True(t, ok, "unexpected discriminator value: %q", petType) {
+		return
+	}
+	schema := spec.RefSchema("#/definitions/" + definition)
+	errs := validate.NewSchemaValidator(schema, doc.Spec(), "", strfmt.Default).Validate(pet).Errors
+	assert.Equal(t, sample.Violations, violationsFrom(errs)&sample.Violations, append(msgAndArgs, pet)...)
+}
+
+func TestGenerator_GenValidationChecksAllBounded(t *testing.T) {
+	// 2^24 combinations of validations: exploration must stop at the default count
+	properties := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		properties = append(properties, `"p`+strconv.Itoa(i)+`": {"type": "integer", "minimum": 1, "maximum": 1000, "multipleOf": 2}`)
+	}
+	schema := testSchema(t, `{"type": "object", "properties": {`+strings.Join(properties, ",")+`}}`)
+
+	gen := Generator{Language: "en", Seed: 1}
+	samples, err := gen.GenValidationChecks("many", schema, true, 0)
+	require.NoError(t, err)
+	assert.Len(t, samples, StubsDefaultValidationChecks)
+	assert.True(t, samples[0].Valid)
+
+	samples, err = gen.GenValidationChecks("many", schema, true, 10)
+	require.NoError(t, err)
+	assert.Len(t, samples, 10)
+}
+
+func TestGenerator_GenerateSamples(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1, "maximum": 1000},
+			"name": {"type": "string", "maxLength": 20}
+		}
+	}`)
+
+	gen := Generator{Language: "en"}
+	samples, err := gen.GenerateSamples("", schema)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.True(t, samples[0].Valid)
+	assert.Equal(t, OriginRandom, samples[0].Origin)
+
+	gen.Args = map[string]interface{}{
+		"valid":               map[string]interface{}{"count": 3},
+		"invalid":             map[string]interface{}{"count": 2},
+		"withValidationCheck": true,
+	}
+	samples, err = gen.GenerateSamples("", schema)
+	require.NoError(t, err)
+	require.Len(t, samples, 3+2+4)
+	for i, sample := range samples {
+		err := validate.AgainstSchema(schema, sample.Value, strfmt.Default)
+		switch {
+		case i < 3:
+			assert.True(t, sample.Valid)
+			assert.Equal(t, OriginRandom, sample.Origin)
+			assert.NoError(t, err)
+		case i < 5:
+			assert.False(t, sample.Valid)
+			assert.Equal(t, OriginRandom, sample.Origin)
+			assert.NotEqual(t, Valid, sample.Violations)
+			assert.Error(t, err)
+		default:
+			assert.False(t, sample.Valid)
+			assert.Equal(t, OriginValidationCheck, sample.Origin)
+			assert.Error(t, err)
+		}
+	}
+
+	// local settings prevail
+	local := testSchema(t, `{"type": "integer", "maximum": 10, "x-datagen": {"args": {"invalid": true}}}`)
+	samples, err = gen.GenerateSamples("", local)
+	require.NoError(t, err)
+	require.Len(t, samples, 3+1+1)
+	assert.True(t, samples[0].Valid)
+	assert.Equal(t, InvalidMaximum, samples[3].Violations)
+
+	gen.Args = map[string]interface{}{"invalid": true}
+	_, err = gen.GenerateSamples("", testSchema(t, `{"type": "integer"}`))
+	assert.Equal(t, ErrNoInvalid, err)
+}
+
+func TestGenerator_GenerateSamplesDiscriminator(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+
+	// invalid samples fail a validation of the subtype picked for each sample
+	for seed := int64(1); seed < 40; seed++ {
+		gen := GeneratorForDocument(doc)
+		gen.Seed = seed
+		gen.Args = map[string]interface{}{"invalid": map[string]interface{}{"count": 3}}
+		samples, err := gen.GenerateSamples("", spec.RefSchema("#/definitions/Pet"))
+		require.NoError(t, err)
+		require.Len(t, samples, 3)
+		for _, sample := range samples {
+			assertPetViolations(t, doc, sample, "seed %d", seed)
+		}
+	}
+}
+
+func TestGenerator_GenerateSamplesDefaultExample(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1, "maximum": 1000, "default": 42},
+			"name": {"type": "string", "maxLength": 20, "example": "fred"},
+			"size": {"type": "string", "enum": ["S", "M", "L"], "default": "M", "example": "L"}
+		}
+	}`)
+
+	gen := Generator{Language: "en"}
+	gen.Args = map[string]interface{}{
+		"valid":       map[string]interface{}{"count": 0},
+		"withDefault": true,
+		"withExample": true,
+	}
+	samples, err := gen.GenerateSamples("", schema)
+	require.NoError(t, err)
+	require.Len(t, samples, 4)
+	for i, sample := range samples {
+		assert.True(t, sample.Valid)
+		assert.NoError(t, validate.AgainstSchema(schema, sample.Value, strfmt.Default), "%d: %v", i, sample.Value)
+	}
+	assert.Equal(t, OriginDefault, samples[0].Origin)
+	assert.Equal(t, int64(42), samples[0].Value.(map[string]interface{})["id"])
+	assert.Equal(t, "M", samples[1].Value.(map[string]interface{})["size"])
+	assert.Equal(t, OriginExample, samples[2].Origin)
+	assert.Equal(t, "fred", samples[2].Value.(map[string]interface{})["name"])
+	assert.Equal(t, "L", samples[3].Value.(map[string]interface{})["size"])
+
+	gen.Args = map[string]interface{}{
+		"valid":       map[string]interface{}{"count": 0},
+		"withDefault": map[string]interface{}{"enabled": false, "tiltDefault": "true", "count": 2},
+	}
+	samples, err = gen.GenerateSamples("", schema)
+	require.NoError(t, err)
+	require.Len(t, samples, 4)
+	for i, sample := range samples {
+		assert.False(t, sample.Valid)
+		assert.Equal(t, OriginDefault, sample.Origin)
+		assert.NotEqual(t, Valid, sample.Violations)
+		assert.Error(t, validate.AgainstSchema(schema, sample.Value, strfmt.Default), "%d: %v", i, sample.Value)
+	}
+
+	// x-example on a parameter
+	param := spec.QueryParam("limit").Typed("integer", "int32")
+	param.WithMaximum(100, false)
+	param.AddExtension("x-example", 10)
+	gen.Args = map[string]interface{}{
+		"valid":       map[string]interface{}{"count": 0},
+		"withExample": map[string]interface{}{"tiltExample": "true"},
+	}
+	samples, err = gen.GenerateSamples("limit", param)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, Sample{Value: int32(10), Valid: true, Origin: OriginExample}, samples[0])
+	assert.Equal(t, Sample{Value: int32(101), Violations: InvalidMaximum, Origin: OriginExample}, samples[1])
+}
+
+func TestGenerator_GenerateSamplesExampleDiscriminator(t *testing.T) {
+	loaded, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+	doc := loaded.Pristine()
+	cat := doc.Spec().Definitions["Cat"]
+	skill := cat.AllOf[1].Properties["huntingSkill"]
+	skill.Example = "lazy"
+	cat.AllOf[1].Properties["huntingSkill"] = skill
+
+	// examples of a subtype are injected in values of this subtype
+	var injected int
+	for seed := int64(1); seed < 40; seed++ {
+		gen := GeneratorForDocument(doc)
+		gen.Seed = seed
+		gen.Args = map[string]interface{}{
+			"valid":       map[string]interface{}{"count": 0},
+			"withExample": true,
+		}
+		samples, err := gen.GenerateSamples("", spec.RefSchema("#/definitions/Pet"))
+		require.NoError(t, err)
+		for _, sample := range samples {
+			pet := sample.Value.(map[string]interface{})
+			assert.Equal(t, "Cat", pet["petType"], "seed %d", seed)
+			assert.Equal(t, "lazy", pet["huntingSkill"], "seed %d", seed)
+			injected++
+		}
+	}
+	assert.NotZero(t, injected)
+}
+
+func TestGenerator_GenerateSamplesEdgeCases(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {
+				"type": "integer", "format": "int32", "minimum": 1, "maximum": 1000,
+				"x-datagen": {"args": {"withEdgeCase": ["7", "2000"]}}
+			},
+			"name": {"type": "string", "maxLength": 5},
+			"born": {"type": "string", "format": "date"},
+			"ratio": {"type": "number", "minimum": 0, "exclusiveMinimum": true}
+		}
+	}`)
+
+	gen := Generator{Language: "en"}
+	gen.Args = map[string]interface{}{
+		"valid":        map[string]interface{}{"count": 0},
+		"withEdgeCase": "standard",
+	}
+	samples, err := gen.GenerateSamples("", schema)
+	require.NoError(t, err)
+	require.NotEmpty(t, samples)
+
+	edges := make(map[string][]interface{})
+	for i, sample := range samples {
+		assert.Equal(t, OriginEdgeCase, sample.Origin)
+		assert.Equal(t, sample.Violations == Valid, sample.Valid)
+		err := validate.AgainstSchema(schema, sample.Value, strfmt.Default)
+		if sample.Valid {
+			assert.NoError(t, err, "%d: %v", i, sample.Value)
+		} else {
+			assert.Error(t, err, "%d: %v", i, sample.Value)
+		}
+		for k, v := range sample.Value.(map[string]interface{}) {
+			edges[k] = append(edges[k], v)
+		}
+	}
+	assert.Contains(t, edges["id"], int32(1))
+	assert.Contains(t, edges["id"], int32(0))
+	assert.Contains(t, edges["id"], int32(1001))
+	assert.Contains(t, edges["id"], int32(math.MaxInt32))
+	assert.Contains(t, edges["id"], int32(7))
+	assert.Contains(t, edges["id"], int32(2000))
+	assert.Contains(t, edges["name"], "")
+	assert.Contains(t, edges["name"], strings.Repeat("\U0001F600", 5))
+	assert.Contains(t, edges["name"], "aaaaaa")
+	assert.Contains(t, edges["born"], "2024-02-29")
+	assert.Contains(t, edges["born"], "1970-01-01")
+	assert.NotContains(t, edges["born"], "")
+	assert.Contains(t, edges["ratio"], math.SmallestNonzeroFloat64)
+	assert.Contains(t, edges["ratio"], float64(0))
+
+	// literal edge values only
+	param := spec.QueryParam("limit").Typed("integer", "int64")
+	param.WithMaximum(100, false)
+	gen.Args = map[string]interface{}{
+		"valid":        map[string]interface{}{"count": 0},
+		"withEdgeCase": []interface{}{100, 101},
+	}
+	samples, err = gen.GenerateSamples("limit", param)
+	require.NoError(t, err)
+	assert.Equal(t, []Sample{
+		{Value: int64(100), Valid: true, Origin: OriginEdgeCase},
+		{Value: int64(101), Violations: InvalidMaximum, Origin: OriginEdgeCase},
+	}, samples)
+
+	// patterns are checked as ECMA-262 patterns
+	code := spec.QueryParam("code").Typed("string", "")
+	code.WithPattern(`^\u0041+$`)
+	gen.Args = map[string]interface{}{
+		"valid":        map[string]interface{}{"count": 0},
+		"withEdgeCase": []interface{}{"AA", "BB"},
+	}
+	samples, err = gen.GenerateSamples("code", code)
+	require.NoError(t, err)
+	assert.Equal(t, []Sample{
+		{Value: "AA", Valid: true, Origin: OriginEdgeCase},
+		{Value: "BB", Violations: InvalidPattern, Origin: OriginEdgeCase},
+	}, samples)
+
+	// literal values which do not match the format are labelled Invalid
+	day := spec.QueryParam("day").Typed("string", "date")
+	gen.Args = map[string]interface{}{
+		"valid":        map[string]interface{}{"count": 0},
+		"withEdgeCase": []interface{}{"2024-02-29", "someday"},
+	}
+	samples, err = gen.GenerateSamples("day", day)
+	require.NoError(t, err)
+	assert.Equal(t, []Sample{
+		{Value: "2024-02-29", Valid: true, Origin: OriginEdgeCase},
+		{Value: "someday", Violations: Invalid, Origin: OriginEdgeCase},
+	}, samples)
+
+	gen.Args = map[string]interface{}{"withEdgeCase": []interface{}{"abc"}}
+	_, err = gen.GenerateSamples("limit", param)
+	assert.Error(t, err)
+}
+
+func TestGenerator_GenerateSamplesEdgeCasesDiscriminator(t *testing.T) {
+	loaded, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+	doc := loaded.Pristine()
+	dog := doc.Spec().Definitions["Dog"]
+	size := dog.AllOf[1].Properties["packSize"]
+	size.AddExtension(XdataGen, map[string]interface{}{"args": map[string]interface{}{"withEdgeCase": []interface{}{"-1"}}})
+	dog.AllOf[1].Properties["packSize"] = size
+
+	// edge cases of a subtype are injected in values of this subtype
+	var injected int
+	for seed := int64(1); seed < 40; seed++ {
+		gen := GeneratorForDocument(doc)
+		gen.Seed = seed
+		gen.Args = map[string]interface{}{"valid": map[string]interface{}{"count": 0}}
+		samples, err := gen.GenerateSamples("", spec.RefSchema("#/definitions/Pet"))
+		require.NoError(t, err)
+		for _, sample := range samples {
+			pet := sample.Value.(map[string]interface{})
+			assert.Contains(t, []interface{}{"doggy", "Puppy"}, pet["petType"], "seed %d", seed)
+			assert.Equal(t, float64(-1), pet["packSize"], "seed %d", seed)
+			assert.Equal(t, InvalidMinimum, sample.Violations)
+			injected++
+		}
+	}
+	assert.NotZero(t, injected)
+}
+
+func TestGenerator_Seed(t *testing.T) {
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id", "name", "address"],
+		"properties": {
+			"id": {"type": "integer", "format": "int64"},
+			"name": {"type": "string"},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]{4}$"},
+			"birthdate": {"type": "string", "format": "date"},
+			"updated": {"type": "string", "format": "date-time"},
+			"ip": {"type": "string", "x-datagen": {"name": "ip"}},
+			"hobby": {"type": "string", "x-datagen": {"name": "noun"}},
+			"ratio": {"type": "number", "minimum": 0, "maximum": 1},
+			"tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b", "c"]}},
+			"address": {
+				"type": "object",
+				"required": ["city"],
+				"properties": {
+					"city": {"type": "string", "x-datagen": {"name": "city"}},
+					"zip": {"type": "string", "format": "uuid"}
+				}
+			}
+		}
+	}`)
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+
+	fixtures := func(seed int64) string {
+		gen := GeneratorForDocument(doc)
+		gen.Seed = seed
+		values := make([]interface{}, 0, 10)
+		for i := 0; i < 5; i++ {
+			res, err := gen.GenSchema("customer", schema)
+			require.NoError(t, err)
+			values = append(values, res)
+			res, err = gen.GenSchema("pet", spec.RefSchema("#/definitions/Pet"))
+			require.NoError(t, err)
+			values = append(values, res)
+		}
+		samples, err := gen.GenValidationChecks("customer", schema, false, 0)
+		require.NoError(t, err)
+		values = append(values, samples)
+		js, err := json.Marshal(values)
+		require.NoError(t, err)
+		return string(js)
+	}
+
+	expected := fixtures(42)
+	assert.Equal(t, expected, fixtures(42))
+	assert.NotEqual(t, expected, fixtures(43))
+}
+
+// TestGenerator_Concurrent shares a generator across parallel subtests: run it with -race
+func TestGenerator_Concurrent(t *testing.T) {
+	doc, err := loads.Spec(filepath.Join("fixtures", "specs", "gentest4.yaml"))
+	require.NoError(t, err)
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["id", "code", "hobby", "ip"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1, "maximum": 1000},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]{4}$"},
+			"hobby": {"type": "string", "x-datagen": {"name": "noun"}},
+			"ip": {"type": "string", "x-datagen": {"name": "ip"}},
+			"born": {"type": "string", "format": "date"}
+		}
+	}`)
+
+	gen := GeneratorForDocument(doc)
+	gen.Seed = 1
+	gen.Args = map[string]interface{}{"invalid": true, "withEdgeCase": true}
+	for i := 0; i < 8; i++ {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+			for j := 0; j < 10; j++ {
+				res, err := gen.GenSchema("customer", schema)
+				require.NoError(t, err)
+				assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default))
+
+				_, err = gen.GenSchema("pet", spec.RefSchema("#/definitions/Pet"))
+				require.NoError(t, err)
+			}
+			samples, err := gen.GenerateSamples("customer", schema)
+			require.NoError(t, err)
+			assert.NotEmpty(t, samples)
+		})
+	}
+}
+
+func TestGenerator_RegisterGenerator(t *testing.T) {
+	RegisterGenerator("test-sku", func(opts GeneratorOpts) (interface{}, error) {
+		return "SKU-0001", nil
+	}, "Test-Stock-Keeping-Unit")
+
+	gen := Generator{Language: "en"}
+	for _, js := range []string{
+		`{"type": "string", "x-datagen": {"name": "test-sku"}}`,
+		`{"type": "string", "x-datagen": {"name": "test-stock-keeping-unit"}}`,
+		`{"type": "string", "format": "test-sku"}`,
+	} {
+		res, err := gen.GenSchema("", testSchema(t, js))
+		require.NoError(t, err)
+		assert.Equal(t, "SKU-0001", res, js)
+	}
+
+	// registered on a single generator, which prevails over the default registry
+	local := Generator{Language: "en"}
+	local.RegisterGenerator("test-sku", func(opts GeneratorOpts) (interface{}, error) {
+		return "LOCAL-" + opts.Args().Lang[0], nil
+	}, "test-customer-id")
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["sku", "id"],
+		"properties": {
+			"sku": {"type": "string", "x-datagen": {"name": "test-sku", "args": {"lang": ["fr"]}}},
+			"id": {"type": "string", "x-datagen": {"name": "test-customer-id", "args": {"lang": ["en"]}}}
+		}
+	}`)
+	res, err := local.GenSchema("", schema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"sku": "LOCAL-fr", "id": "LOCAL-en"}, res)
+
+	// other generators are not affected
+	_, err = gen.GenSchema("", schema)
+	assert.Error(t, err)
+
+	// custom generators abide by length validations
+	_, err = gen.GenSchema("", testSchema(t, `{"type": "string", "maxLength": 3, "x-datagen": {"name": "test-sku"}}`))
+	assert.Equal(t, ErrNoValid, err)
+}
+
+func TestGenerator_Verify(t *testing.T) {
+	gen := Generator{Language: "en", Verify: true}
+
+	// amounts abide by the bounds of the schema, not only by the default range of amounts
+	for _, js := range []string{
+		`{"type": "number", "maximum": 50, "x-datagen": {"name": "amount"}}`,
+		`{"type": "number", "minimum": 2000000, "x-datagen": {"name": "amount"}}`,
+		`{"type": "number", "minimum": 1, "maximum": 5, "x-datagen": {"name": "small-amount"}}`,
+		`{"type": "number", "minimum": 10, "maximum": 20, "multipleOf": 0.25, "x-datagen": {"name": "price"}}`,
+	} {
+		schema := testSchema(t, js)
+		for i := 0; i < 20; i++ {
+			res, err := gen.GenSchema("", schema)
+			require.NoError(t, err, js)
+			assert.NoError(t, validate.AgainstSchema(schema, res, strfmt.Default), "%s: %v", js, res)
+		}
+	}
+
+	// invalid samples fail the targeted validations
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["price", "code"],
+		"properties": {
+			"price": {"type": "number", "minimum": 1, "maximum": 50},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}$", "maxLength": 3}
+		}
+	}`)
+	samples, err := gen.GenValidationChecks("", schema, false, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, samples)
+	for _, sample := range samples {
+		err := validate.AgainstSchema(schema, sample.Value, strfmt.Default)
+		assert.Error(t, err, "%v", sample.Value)
+	}
+
+	// optional values with an invalid mode are always generated
+	schema = testSchema(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "minimum": 0, "maximum": 10, "x-datagen": {"mode": 4}},
+			"name": {"type": "string", "maxLength": 20}
+		}
+	}`)
+	for _, g := range []*Generator{&gen, {Language: "en"}} {
+		for i := 0; i < 20; i++ {
+			res, err := g.GenSchema("", schema)
+			require.NoError(t, err)
+			assert.Contains(t, res, "id")
+			assert.Error(t, validate.AgainstSchema(schema, res, strfmt.Default), "%v", res)
+		}
+	}
+
+	// values which cannot be verified are reported
+	gen.RegisterGenerator("test-bad-code", func(opts GeneratorOpts) (interface{}, error) {
+		return "abc", nil
+	})
+	_, err = gen.GenSchema("", testSchema(t, `{"type": "string", "pattern": "^[0-9]+$", "x-datagen": {"name": "test-bad-code"}}`))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNoValid))
+	assert.Contains(t, err.Error(), "should match")
+}
+
+func TestGenerator_Explain(t *testing.T) {
+	gen := Generator{Language: "en"}
+	schema := testSchema(t, `{
+		"type": "object",
+		"required": ["email"],
+		"properties": {
+			"email": {"type": "string", "title": "customerEmailAddress", "maxLength": 64},
+			"birthday": {"type": "string", "format": "date", "title": "birthday"},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}$"},
+			"status": {"type": "string", "title": "status", "description": "The state of the order", "enum": ["open", "closed"]},
+			"price": {"type": "number", "minimum": 1, "x-datagen": {"name": "small-amount", "args": {"max": 50}}},
+			"city": {"type": "string", "x-datagen": {"name": "town"}},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 3},
+			"a/b": {"type": "object", "properties": {"c": {"type": "integer"}}}
+		}
+	}`)
+
+	explanation, err := gen.Explain("customer", schema)
+	require.NoError(t, err)
+	require.NotNil(t, explanation)
+	assert.Equal(t, "", explanation.Path)
+	assert.Equal(t, "object", explanation.Generator)
+	require.Len(t, explanation.Nested, 8)
+
+	nested := make(map[string]*Explanation, len(explanation.Nested))
+	for _, e := range explanation.Nested {
+		nested[e.Path] = e
+	}
+	elected := func(e *Explanation) Decision {
+		for _, d := range e.Decisions {
+			if d.Elected {
+				return d
+			}
+		}
+		return Decision{}
+	}
+
+	email := nested["/email"]
+	require.NotNil(t, email)
+	assert.Equal(t, "email", email.Generator)
+	assert.Equal(t, "fuzzy", elected(email).Rule)
+	assert.Greater(t, elected(email).Score, float64(minFuzzyConfidence))
+	assert.Equal(t, map[string]interface{}{"maxLength": int64(64), "required": true}, email.Constraints)
+
+	birthday := nested["/birthday"]
+	require.NotNil(t, birthday)
+	assert.Equal(t, "birthdate", birthday.Generator)
+	assert.Equal(t, []string{"type", "format", "fuzzy"}, rulesOf(birthday.Decisions))
+
+	code := nested["/code"]
+	require.NotNil(t, code)
+	assert.Equal(t, Decision{Rule: "pattern", Generator: "pattern", Score: 1, Elected: true}, elected(code))
+	assert.Equal(t, "^[A-Z]{3}$", code.Constraints["pattern"])
+
+	// a fuzzy guess below the confidence threshold is reported, but not elected
+	status := nested["/status"]
+	require.NotNil(t, status)
+	assert.Equal(t, "enum", status.Generator)
+	for _, d := range status.Decisions {
+		if d.Rule == "fuzzy" {
+			assert.Less(t, d.Score, float64(minFuzzyConfidence))
+			assert.False(t, d.Elected)
+		}
+	}
+
+	price := nested["/price"]
+	require.NotNil(t, price)
+	assert.Equal(t, []Decision{{Rule: "x-datagen", Generator: "small-amount", Score: 1, Elected: true}}, price.Decisions)
+	assert.Equal(t, map[string]interface{}{"max": float64(50)}, price.Args)
+	assert.Equal(t, map[string]interface{}{"minimum": float64(1)}, price.Constraints)
+
+	// an unknown generator falls back to the name of the field
+	city := nested["/city"]
+	require.NotNil(t, city)
+	assert.Equal(t, "city", city.Generator)
+	assert.Equal(t, []Decision{
+		{Rule: "x-datagen", Generator: "town", Score: 1},
+		{Rule: "field-name", Generator: "city", Score: 1, Elected: true},
+	}, city.Decisions)
+
+	tags := nested["/tags"]
+	require.NotNil(t, tags)
+	assert.Equal(t, "array", tags.Generator)
+	require.Len(t, tags.Nested, 1)
+	assert.Equal(t, "/tags/items", tags.Nested[0].Path)
+	assert.Equal(t, "sentence", tags.Nested[0].Generator)
+
+	// paths are escaped as JSON pointers
+	slashed := nested["/a~1b"]
+	require.NotNil(t, slashed)
+	assert.Equal(t, "a/b", slashed.Field)
+	require.Len(t, slashed.Nested, 1)
+	assert.Equal(t, "/a~1b/c", slashed.Nested[0].Path)
+}
+
+// countingRuler counts its decisions
+type countingRuler struct {
+	decided int
+}
+
+func (c *countingRuler) Decide() basicGeneratorOpts {
+	c.decided++
+	return &genOpts{name: "email"}
+}
+
+func TestGenerator_ExplainDecidesOnce(t *testing.T) {
+	rule := &countingRuler{}
+	fuzzy := newFuzzyRulerFor(&spec.Schema{SchemaProps: spec.SchemaProps{Type: spec.StringOrArray{"string"}, Title: "city"}})
+	opts := &genOpts{rules: []ruler{rule, fuzzy}}
+
+	decisions := opts.explain()
+	assert.Equal(t, 1, rule.decided)
+	assert.Equal(t, []string{"*stubs.countingRuler", "fuzzy"}, rulesOf(decisions))
+	assert.True(t, decisions[1].Elected)
+	assert.Equal(t, "city", opts.Name())
+}
+
+func rulesOf(decisions []Decision) []string {
+	rules := make([]string, 0, len(decisions))
+	for _, d := range decisions {
+		rules = append(rules, d.Rule)
+	}
+	return rules
+}
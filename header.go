@@ -21,14 +21,15 @@ func headerGenOpts(key string, header *spec.Header) (*simpleOpts, error) {
 
 		gopts.rules = append(gopts.rules, newTypeRulerFor(header))
 
-		if header.Type == "string" {
-			gopts.rules = append(gopts.rules, newFuzzyRulerFor(header))
-		}
-
 		if header.Format != "" {
 			gopts.rules = append(gopts.rules, newFormatRulerFor(header))
 		}
 
+		// fuzzy matches are more specific than formats
+		if fuzzyTypes[header.Type] {
+			gopts.rules = append(gopts.rules, newFuzzyRulerFor(header))
+		}
+
 		if header.Pattern != "" {
 			gopts.rules = append(gopts.rules, newPatternRulerFor(header))
 		}
@@ -36,6 +37,10 @@ func headerGenOpts(key string, header *spec.Header) (*simpleOpts, error) {
 		if header.Items != nil {
 			gopts.rules = append(gopts.rules, newItemsRulerFor(header.Items))
 		}
+
+		if len(header.Enum) > 0 {
+			gopts.rules = append(gopts.rules, newEnumRulerFor(header))
+		}
 	}
 
 	if key == "" {
@@ -48,5 +53,6 @@ func headerGenOpts(key string, header *spec.Header) (*simpleOpts, error) {
 		CommonValidations: header.CommonValidations,
 		SimpleSchema:      header.SimpleSchema,
 		required:          true,
+		example:           exampleOf(header.Example, header.Extensions),
 	}, nil
 }
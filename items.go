@@ -1,20 +1,51 @@
 package stubs
 
 import (
+	"fmt"
+
 	"github.com/go-openapi/spec"
 )
 
-// NOTE: unused
+// itemsGenOpts generates stubs for the items of a simple array
 func itemsGenOpts(key string, items *spec.Items) (*simpleOpts, error) {
 	var gopts genOpts
+	if items == nil || items.Type == "" { // Safeguard
+		return nil, fmt.Errorf("no items defined for array [%s]", key)
+	}
 	if err := gopts.ExtOverride(items.Extensions); err != nil {
+		debugLog("extension error on %s: %v", gopts.Name(), err)
 		return nil, err
 	}
+	debugLog("generator override: %s", gopts.Name())
+	if gopts.Name() == "" {
+		// register rules to infer options
+		gopts.rules = make([]ruler, 0, 50)
+
+		gopts.rules = append(gopts.rules, newTypeRulerFor(items))
+
+		if items.Format != "" {
+			gopts.rules = append(gopts.rules, newFormatRulerFor(items))
+		}
+
+		if items.Pattern != "" {
+			gopts.rules = append(gopts.rules, newPatternRulerFor(items))
+		}
+
+		if items.Items != nil {
+			gopts.rules = append(gopts.rules, newItemsRulerFor(items.Items))
+		}
+
+		if len(items.Enum) > 0 {
+			gopts.rules = append(gopts.rules, newEnumRulerFor(items))
+		}
+	}
+
 	return &simpleOpts{
 		genOpts:           gopts,
 		fieldName:         key,
 		CommonValidations: items.CommonValidations,
 		SimpleSchema:      items.SimpleSchema,
 		required:          true,
+		example:           exampleOf(items.Example, items.Extensions),
 	}, nil
 }
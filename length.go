@@ -0,0 +1,142 @@
+package stubs
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLengthRetries is the number of attempts made to regenerate a value which fits length validations
+const maxLengthRetries = 100
+
+// lengthFitting describes how a generated string is adjusted to fit length validations
+type lengthFitting uint8
+
+const (
+	// fitByRegenerating draws new values until one fits: this preserves the semantics of formatted values,
+	// such as emails or uuids
+	fitByRegenerating lengthFitting = iota
+	// fitByPadding pads free text with words, or truncates it
+	fitByPadding
+)
+
+// textGenerators are the generators producing free text, which may be padded or truncated at will
+var textGenerators = map[string]bool{
+	"adjective":  true,
+	"characters": true,
+	"noun":       true,
+	"paragraph":  true,
+	"sentence":   true,
+	"word":       true,
+}
+
+// fittingFor yields the way to adjust values produced by a generator to length validations
+func fittingFor(name string) lengthFitting {
+	if textGenerators[name] {
+		return fitByPadding
+	}
+	return fitByRegenerating
+}
+
+// fitLength wraps a value generator so that generated strings abide by the MinLength and MaxLength validations.
+//
+// In InvalidMinLength or InvalidMaxLength modes, the generated string deliberately violates the corresponding bound.
+// These invalid modes are mutually exclusive.
+//
+// Values which are not strings are left untouched.
+func (g *generators) fitLength(name string, gen ValueGenerator) ValueGenerator {
+	fitting := fittingFor(name)
+	return func(opts GeneratorOpts) (interface{}, error) {
+		value, err := gen(opts)
+		if err != nil || opts == nil {
+			return value, err
+		}
+		str, isString := value.(string)
+		if !isString {
+			return value, nil
+		}
+
+		lo, hi, err := lengthBounds(opts)
+		if err != nil {
+			return nil, err
+		}
+		mode := opts.Mode()
+
+		if fitsLength(str, lo, hi) {
+			return str, nil
+		}
+		debugLog("fitting generated value of length %d into [%d, %d]", utf8.RuneCountInString(str), lo, hi)
+
+		if fitting == fitByRegenerating {
+			for i := 0; i < maxLengthRetries; i++ {
+				value, err = gen(opts)
+				if err != nil {
+					return value, err
+				}
+				if str, isString = value.(string); isString && fitsLength(str, lo, hi) {
+					return str, nil
+				}
+			}
+			if mode == Valid {
+				return nil, ErrNoValid
+			}
+			// an invalid value is not required to preserve semantics: pad or truncate as a last resort
+		}
+		return g.padOrTruncate(str, lo, hi), nil
+	}
+}
+
+// lengthBounds yields the range [lo, hi] of lengths a generated string should fit in, according to the
+// MinLength and MaxLength validations and the mode. A negative hi means unbounded.
+//
+// The InvalidMinLength and InvalidMaxLength modes are mutually exclusive.
+func lengthBounds(opts GeneratorOpts) (int64, int64, error) {
+	minLength, definedMin := opts.MinLength()
+	maxLength, definedMax := opts.MaxLength()
+	if !definedMax {
+		maxLength = -1
+	}
+	mode := opts.Mode()
+
+	switch {
+	// clear edge cases
+	case mode.Has(InvalidMinLength) && (!definedMin || minLength <= 0): // Safeguard
+		return 0, 0, ErrNoInvalid
+	case mode.Has(InvalidMaxLength) && !definedMax: // Safeguard
+		return 0, 0, ErrNoInvalid
+
+		// mutually exclusive failures
+	case mode.Has(InvalidMinLength):
+		return 0, minLength - 1, nil
+	case mode.Has(InvalidMaxLength):
+		return maxLength + 1, -1, nil
+	case definedMin && definedMax && minLength > maxLength:
+		debugLog("minLength %d is incompatible with maxLength %d", minLength, maxLength)
+		return 0, 0, ErrNoValid
+	default:
+		return minLength, maxLength, nil
+	}
+}
+
+// fitsLength tells if the length of a string lies in [lo, hi]. A negative hi means unbounded.
+func fitsLength(str string, lo, hi int64) bool {
+	l := int64(utf8.RuneCountInString(str))
+	return l >= lo && (hi < 0 || l <= hi)
+}
+
+// padOrTruncate adjusts a free text string to a length within [lo, hi]. A negative hi means unbounded.
+func (g *generators) padOrTruncate(str string, lo, hi int64) string {
+	runes := []rune(str)
+	for int64(len(runes)) < lo {
+		// pad with more words
+		runes = append(runes, []rune(" "+strings.Join(g.faker.Words(StubsDefaultWordCount, false), " "))...)
+	}
+	if hi >= 0 && int64(len(runes)) > hi {
+		// pick a length within bounds, then truncate
+		runes = runes[:g.entropy.Int64Between(lo, hi)]
+	}
+	if l := len(runes); l > 0 && runes[l-1] == ' ' {
+		// avoid a trailing blank after truncation
+		runes = append(runes[:l-1], []rune(g.faker.Characters(1))...)
+	}
+	return string(runes)
+}
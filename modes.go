@@ -9,7 +9,8 @@ func (s StubMode) Has(m StubMode) bool {
 }
 
 const (
-	// Invalid produces a stub which is invalid for a random validation
+	// Invalid produces a stub which is invalid for a random validation.
+	// In the violations of a sample, it stands for failures without a dedicated mode, such as an invalid format.
 	Invalid StubMode = 1 << iota
 	// InvalidRequired produces a stub which is invalid for required
 	InvalidRequired
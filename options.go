@@ -1,6 +1,10 @@
 package stubs
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
+
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -11,6 +15,16 @@ const (
 	StubsDefaultWordCount = 10
 	// StubsDefaultStringLength is the default length for generated strings
 	StubsDefaultStringLength = 30
+	// StubsDefaultItemsCount is the default maximum number of items generated in arrays. It may be overriden with the "length" argument.
+	StubsDefaultItemsCount = 5
+	// StubsDefaultPropertiesCount is the default maximum number of additional or pattern properties generated in objects.
+	// It may be overriden with the "length" argument.
+	StubsDefaultPropertiesCount = 3
+	// StubsDefaultValidationChecks is the default maximum number of samples generated when checking all combinations
+	// of validations. It may be overriden with the "count" argument.
+	StubsDefaultValidationChecks = 50
+	// StubsDefaultMaxDepth is the default number of times a recursive $ref is followed during generation
+	StubsDefaultMaxDepth = 3
 	// StubsDefaultSupplemental defines if "supplemental" words from extra dictionary in faker is enabled
 	StubsDefaultSupplemental = true
 	// StubsDefaultMaxAmount defines the default max amount for prices and other currency amounts
@@ -21,6 +35,12 @@ const (
 	StubsDefaultMaxSmallAmount = 1000
 	// StubsDefaultMinSmallAmount defines the default min amount for small prices and other small currency amounts
 	StubsDefaultMinSmallAmount = 10
+	// StubsDefaultMaxAge defines the default max age of persons, in years
+	StubsDefaultMaxAge = 90
+	// StubsDefaultMinAge defines the default min age of persons, in years
+	StubsDefaultMinAge = 18
+	// StubsDefaultRecentDays defines how many days in the past recent date-times go
+	StubsDefaultRecentDays = 30
 )
 
 // basicGeneratorOpts publishes basic operations for GeneratorOpts
@@ -40,16 +60,19 @@ type basicGeneratorOpts interface {
 
 	// Infer deduces the generator to be used from inference rules
 	Infer()
+	// explain deduces the generator to be used like Infer, and reports the decisions of the inference rules
+	explain() []Decision
 
 	// ExtOverride captures the arguments under the x-datagen extension
 	ExtOverride(map[string]interface{}) error
 	// SetArgs overrides Args
 	SetArgs(*argTags)
+	// SetMode overrides Mode
+	SetMode(StubMode)
 }
 
 // GeneratorOpts interface to capture various types that can get data generated for them.
 type GeneratorOpts interface {
-	seeder
 	basicGeneratorOpts
 
 	// FieldName for the value generator, this is mostly used as an alternative to the name
@@ -100,11 +123,23 @@ type GeneratorOpts interface {
 	Required() bool
 }
 
+// boolOrMap is an argument which may be specified as a boolean, or as a map of arguments, which enables it
 type boolOrMap struct {
 	Enabled bool
 	Args    map[string]string
 }
 
+// Count yields the "count" argument, or a default value when not specified
+func (b *boolOrMap) Count(def int) int {
+	if c, ok := b.Args["count"]; ok {
+		if count, err := strconv.Atoi(c); err == nil {
+			return count
+		}
+	}
+	return def
+}
+
+// boolOrSlice is an argument which may be specified as a boolean, or as a list of arguments, which enables it
 type boolOrSlice struct {
 	Enabled bool
 	Args    []string
@@ -135,9 +170,16 @@ type argTags struct {
 	WithDefault             boolOrMap   `mapstructure:"withDefault"`
 	WithEdgeCase            boolOrSlice `mapstructure:"withEdgeCase"`
 	WithTilting             boolOrMap   `mapstructure:"withTilting"`
+	WithValidationCheck     boolOrMap   `mapstructure:"withValidationCheck"`
 	WithAllValidationChecks boolOrMap   `mapstructure:"withAllValidationChecks"`
 	SkipTilting             bool        `mapstructure:"skipTilting"`
 	SkipFuzzying            bool        `mapstructure:"skipFuzzying"`
+	// Subtype pins the subtype generated for a definition with a discriminator
+	Subtype string `mapstructure:"subtype"`
+	// Subtypes weights the choice of subtypes generated for a definition with a discriminator
+	Subtypes map[string]float64 `mapstructure:"subtypes"`
+	// Enum weights the choice of values drawn from an enum
+	Enum map[string]float64 `mapstructure:"enum"`
 }
 
 // genTag describe the structure of a x-datagen hint in the swagger spec
@@ -153,6 +195,8 @@ type genOpts struct {
 	args  argTags  // args define specific behavior expected from the generator
 	mode  StubMode // mode selects the stub generation strategy
 	rules []ruler  // rules is the ordered list of rules used to infer the generator options
+
+	datagen bool // datagen is true when the generator is named by the x-datagen extension
 }
 
 func (g *genOpts) Name() string {
@@ -171,14 +215,18 @@ func (g *genOpts) SetArgs(args *argTags) {
 	g.args = *args
 }
 
+func (g *genOpts) SetMode(mode StubMode) {
+	g.mode = mode
+}
+
 // Infer chains inference rules to take a decision about the generator option to set
 func (g *genOpts) Infer() {
-	var decisions = make([]basicGeneratorOpts, 0, 50)
+	var decisions = make([]basicGeneratorOpts, 0, len(g.rules))
 	for _, rule := range g.rules {
 		decision := rule.Decide()
 		if decision != nil {
 			debugLog("decision: %v", decision)
-			decisions = append(decisions, rule.Decide())
+			decisions = append(decisions, decision)
 		}
 	}
 	g.merge(decisions)
@@ -204,13 +252,81 @@ func (g *genOpts) ExtOverride(extensions map[string]interface{}) error {
 		// TODO: factorize in loadOpts()
 		// whenever a x-datagen extension is specified, take its directives for granted
 		tag := genTag{}
-		if err := mapstructure.WeakDecode(ext, &tag); err != nil {
+		if err := decodeArgs(ext, &tag); err != nil {
 			return err
 		}
 		g.name = tag.Name
 		g.args = tag.Args
 		g.mode = tag.Mode
+		g.datagen = tag.Name != ""
 		return nil
 	}
 	return nil
 }
+
+// decodeArgs decodes a x-datagen extension, or some arguments with the same structure
+func decodeArgs(input, target interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       argsDecodeHook,
+		WeaklyTypedInput: true,
+		Result:           target,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
+
+// argsDecodeHook decodes arguments which may be specified either as a boolean or as a map or slice of arguments.
+//
+// Examples:
+//
+//	withDefault: true
+//	valid: {count: 3}
+//	withEdgeCase: [standard]
+func argsDecodeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	switch to {
+	case reflect.TypeOf(boolOrMap{}):
+		res := boolOrMap{}
+		switch from.Kind() {
+		case reflect.Bool:
+			res.Enabled = data.(bool)
+		case reflect.Map:
+			res.Enabled = true
+			res.Args = make(map[string]string)
+			value := reflect.ValueOf(data)
+			for _, k := range value.MapKeys() {
+				key := fmt.Sprintf("%v", k.Interface())
+				arg := fmt.Sprintf("%v", value.MapIndex(k).Interface())
+				if key == "enabled" {
+					res.Enabled, _ = strconv.ParseBool(arg)
+					continue
+				}
+				res.Args[key] = arg
+			}
+		default:
+			return data, nil
+		}
+		return res, nil
+	case reflect.TypeOf(boolOrSlice{}):
+		res := boolOrSlice{}
+		switch from.Kind() {
+		case reflect.Bool:
+			res.Enabled = data.(bool)
+		case reflect.String:
+			res.Enabled = true
+			res.Args = []string{data.(string)}
+		case reflect.Slice:
+			res.Enabled = true
+			value := reflect.ValueOf(data)
+			for i := 0; i < value.Len(); i++ {
+				res.Args = append(res.Args, fmt.Sprintf("%v", value.Index(i).Interface()))
+			}
+		default:
+			return data, nil
+		}
+		return res, nil
+	default:
+		return data, nil
+	}
+}
@@ -22,14 +22,15 @@ func paramGenOpts(key string, param *spec.Parameter) (*simpleOpts, error) {
 
 		gopts.rules = append(gopts.rules, newTypeRulerFor(param))
 
-		if param.Type == "string" {
-			gopts.rules = append(gopts.rules, newFuzzyRulerFor(param))
-		}
-
 		if param.Format != "" {
 			gopts.rules = append(gopts.rules, newFormatRulerFor(param))
 		}
 
+		// fuzzy matches are more specific than formats
+		if fuzzyTypes[param.Type] {
+			gopts.rules = append(gopts.rules, newFuzzyRulerFor(param))
+		}
+
 		if param.Pattern != "" {
 			gopts.rules = append(gopts.rules, newPatternRulerFor(param))
 		}
@@ -37,6 +38,10 @@ func paramGenOpts(key string, param *spec.Parameter) (*simpleOpts, error) {
 		if param.Items != nil {
 			gopts.rules = append(gopts.rules, newItemsRulerFor(param.Items))
 		}
+
+		if len(param.Enum) > 0 {
+			gopts.rules = append(gopts.rules, newEnumRulerFor(param))
+		}
 	}
 
 	if key == "" {
@@ -49,5 +54,6 @@ func paramGenOpts(key string, param *spec.Parameter) (*simpleOpts, error) {
 		CommonValidations: param.CommonValidations,
 		SimpleSchema:      param.SimpleSchema,
 		required:          param.Required,
+		example:           exampleOf(param.Example, param.Extensions),
 	}, nil
 }
@@ -0,0 +1,179 @@
+package stubs
+
+import (
+	"regexp/syntax"
+)
+
+// maxRepeatCount is the largest repetition count accepted by regexp/syntax
+const maxRepeatCount = 1000
+
+// boundPattern rewrites a pattern so that the strings it generates have a length within [lo, hi].
+// A negative hi means unbounded.
+//
+// Repetitions (*, +, ?, {n,m}) are given explicit bounds: minimum counts are raised to reach lo, then
+// maximum counts are lowered to share the room left below hi among all repetitions.
+// This is a best effort: alternatives of different lengths may still yield strings out of bounds.
+//
+// It returns ErrNoValid when no string matching the pattern may fit the bounds.
+func (g *generators) boundPattern(pattern string, lo, hi int64) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	repeats := repeatsOf(re, nil)
+
+	if lo > 0 {
+		for _, r := range repeats {
+			shortest, _ := patternLength(re)
+			need := lo - shortest
+			if need <= 0 {
+				break
+			}
+			subShortest, _ := patternLength(r.Sub[0])
+			if subShortest == 0 {
+				continue
+			}
+			count := r.Min + int((need+subShortest-1)/subShortest)
+			if r.Max >= 0 && count > r.Max {
+				count = r.Max
+			}
+			r.Min = boundRepeatCount(count)
+		}
+	}
+
+	if hi >= 0 {
+		if shortest, _ := patternLength(re); shortest > hi {
+			debugLog("pattern %q cannot match strings shorter than %d", pattern, shortest)
+			return "", ErrNoValid
+		}
+		caps := make([]int, len(repeats))
+		for i, r := range repeats {
+			caps[i] = r.Max
+			r.Max = r.Min
+		}
+		order := g.entropy.Perm(len(repeats))
+		for n, i := range order {
+			r := repeats[i]
+			_, longest := patternLength(re)
+			room := hi - longest
+			if room <= 0 {
+				break
+			}
+			_, subLongest := patternLength(r.Sub[0])
+			// the last repetitions get the room left by the previous ones
+			share := room / int64(len(order)-n)
+			if n == len(order)-1 {
+				share = room
+			}
+			count := maxRepeatCount
+			if subLongest > 0 {
+				count = r.Min + int(share/subLongest)
+			}
+			if caps[i] >= 0 && count > caps[i] {
+				count = caps[i]
+			}
+			r.Max = boundRepeatCount(count)
+		}
+	}
+
+	bounded := re.String()
+	if _, err := syntax.Parse(bounded, syntax.Perl); err != nil {
+		// nested repetitions may exceed the limits of regexp/syntax
+		debugLog("cannot bound pattern %q: %v", pattern, err)
+		return pattern, nil
+	}
+	return bounded, nil
+}
+
+// repeatsOf collects all repetitions in a regular expression, innermost first.
+//
+// Repetitions are normalized as OpRepeat, with Max=-1 when unbounded.
+func repeatsOf(re *syntax.Regexp, repeats []*syntax.Regexp) []*syntax.Regexp {
+	for _, sub := range re.Sub {
+		repeats = repeatsOf(sub, repeats)
+	}
+	switch re.Op {
+	case syntax.OpStar:
+		re.Min, re.Max = 0, -1
+	case syntax.OpPlus:
+		re.Min, re.Max = 1, -1
+	case syntax.OpQuest:
+		re.Min, re.Max = 0, 1
+	case syntax.OpRepeat:
+	default:
+		return repeats
+	}
+	re.Op = syntax.OpRepeat
+	return append(repeats, re)
+}
+
+// patternLength yields the lengths, in runes, of the shortest and longest strings matching a regular expression.
+// The longest length is -1 when unbounded.
+func patternLength(re *syntax.Regexp) (int64, int64) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return int64(len(re.Rune)), int64(len(re.Rune))
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return 1, 1
+	case syntax.OpCapture:
+		return patternLength(re.Sub[0])
+	case syntax.OpConcat:
+		var shortest, longest int64
+		for _, sub := range re.Sub {
+			s, l := patternLength(sub)
+			shortest += s
+			if longest >= 0 {
+				longest = addLength(longest, l)
+			}
+		}
+		return shortest, longest
+	case syntax.OpAlternate:
+		shortest, longest := patternLength(re.Sub[0])
+		for _, sub := range re.Sub[1:] {
+			s, l := patternLength(sub)
+			if s < shortest {
+				shortest = s
+			}
+			if longest >= 0 && (l < 0 || l > longest) {
+				longest = l
+			}
+		}
+		return shortest, longest
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			lo, hi = 0, -1
+		case syntax.OpPlus:
+			lo, hi = 1, -1
+		case syntax.OpQuest:
+			lo, hi = 0, 1
+		}
+		s, l := patternLength(re.Sub[0])
+		switch {
+		case l == 0:
+			return s * int64(lo), 0
+		case hi < 0 || l < 0:
+			return s * int64(lo), -1
+		default:
+			return s * int64(lo), l * int64(hi)
+		}
+	default:
+		// anchors, word boundaries and empty matches
+		return 0, 0
+	}
+}
+
+func boundRepeatCount(count int) int {
+	if count > maxRepeatCount {
+		return maxRepeatCount
+	}
+	return count
+}
+
+func addLength(longest, l int64) int64 {
+	if l < 0 {
+		return -1
+	}
+	return longest + l
+}
@@ -1,7 +1,7 @@
 package stubs
 
 import (
-	"log"
+	"fmt"
 	"math"
 	"math/rand"
 )
@@ -23,41 +23,37 @@ const (
 )
 
 type randomGenerator struct {
-	// TODO: rand generator in struct
-	defaultSeeder
+	rnd *rand.Rand
 	// TODO: custom boundaries
 	// TODO: non uniform distributions
 }
 
-// randomO:pts describes options for the source of entropy
+// randomOpts describes options for the source of entropy
 type randomOpts struct {
-	AutoSeed bool
+	Seed int64
 }
 
 func newRandomGenerator(opts randomOpts) *randomGenerator {
-	r := randomGenerator{}
-	r.autoseed = opts.AutoSeed
-	debugLog("autoseed: %t", r.autoseed)
-	return &r
+	debugLog("seed: %d", opts.Seed)
+	return &randomGenerator{rnd: rand.New(rand.NewSource(opts.Seed))}
 }
 
 // IntN returns a random integer
 func (r *randomGenerator) IntN(n int) int {
-	if r.autoseed {
-		r.AutoSeed()
-	}
-	return rand.Intn(n)
+	return r.rnd.Intn(n)
+}
+
+// Perm returns a random permutation of the integers in [0, n)
+func (r *randomGenerator) Perm(n int) []int {
+	return r.rnd.Perm(n)
 }
 
 func (r *randomGenerator) Bool() bool {
-	if r.autoseed {
-		r.AutoSeed()
-	}
-	return rand.Intn(2) == 1
+	return r.rnd.Intn(2) == 1
 }
 
 func (r *randomGenerator) Sign() int {
-	if rand.Intn(2) == 1 {
+	if r.rnd.Intn(2) == 1 {
 		return 1
 	}
 	return -1
@@ -76,11 +72,7 @@ func (r *randomGenerator) Float64(min, max float64) float64 {
 	if min == max {
 		return min
 	}
-	if r.autoseed {
-		r.AutoSeed()
-	}
-	log.Printf("DEBUG: min: %v, max: %v", min, max)
-	return min + rand.Float64()*(max-min)
+	return min + r.rnd.Float64()*(max-min)
 }
 
 func (r *randomGenerator) Float32(min, max float32) float32 {
@@ -94,10 +86,7 @@ func (r *randomGenerator) Float32(min, max float32) float32 {
 	if min == max {
 		return min
 	}
-	if r.autoseed {
-		r.AutoSeed()
-	}
-	return min + rand.Float32()*(max-min)
+	return min + r.rnd.Float32()*(max-min)
 }
 
 func (r *randomGenerator) Int64(min, max int64) int64 {
@@ -111,10 +100,48 @@ func (r *randomGenerator) Int64(min, max int64) int64 {
 	if min == max {
 		return min
 	}
-	if r.autoseed {
-		r.AutoSeed()
+	return min + r.rnd.Int63n(max-min+1)
+}
+
+// Int64Between returns a random integer in the closed interval [lo, hi]
+func (r *randomGenerator) Int64Between(lo, hi int64) int64 {
+	if lo >= hi {
+		return lo
 	}
-	return min + rand.Int63n(max-min+1)
+	span := uint64(hi) - uint64(lo)
+	if span < math.MaxInt64 {
+		return lo + r.rnd.Int63n(int64(span)+1)
+	}
+	// the range is larger than what Int63n supports
+	n := r.rnd.Uint64()
+	for n > span {
+		n = r.rnd.Uint64()
+	}
+	return int64(uint64(lo) + n)
+}
+
+// Weighted selects at random an index in a slice of positive weights.
+//
+// It returns an error when the weights do not sum up to a positive total.
+func (r *randomGenerator) Weighted(weights []float64) (int, error) {
+	total := float64(0)
+	for _, w := range weights {
+		total += w
+	}
+	if !(total > 0) {
+		return 0, fmt.Errorf("cannot pick a weighted choice with a total weight of %v", total)
+	}
+	if len(weights) == 1 {
+		return 0, nil
+	}
+	x := r.Float64(0, total)
+	for i, w := range weights {
+		if x < w {
+			return i, nil
+		}
+		x -= w
+	}
+	return len(weights) - 1, nil
 }
 
 func (r *randomGenerator) Int32(min, max int32) int32 {
@@ -128,10 +155,7 @@ func (r *randomGenerator) Int32(min, max int32) int32 {
 	if min == max {
 		return min
 	}
-	if r.autoseed {
-		r.AutoSeed()
-	}
-	return min + rand.Int31n(max-min+int32(1))
+	return min + r.rnd.Int31n(max-min+int32(1))
 }
 
 func (r *randomGenerator) Uint64(min, max uint64) uint64 {
@@ -144,14 +168,11 @@ func (r *randomGenerator) Uint64(min, max uint64) uint64 {
 	if min == max {
 		return min
 	}
-	if r.autoseed {
-		r.AutoSeed()
-	}
 	if max-min > defaultMaxInt64 {
 		// TODO: make range > maxInt32 reachable
 		debugLog("warning unsupported range")
 	}
-	return min + uint64(rand.Int63n(int64(max-min+uint64(1))))
+	return min + uint64(r.rnd.Int63n(int64(max-min+uint64(1))))
 }
 
 func (r *randomGenerator) Uint32(min, max uint32) uint32 {
@@ -164,13 +185,10 @@ func (r *randomGenerator) Uint32(min, max uint32) uint32 {
 	if min == max {
 		return min
 	}
-	if r.autoseed {
-		r.AutoSeed()
-	}
 	if max-min > defaultMaxInt32 {
 		// TODO: make range > maxInt32 reachable
 		debugLog("warning unsupported range")
 	}
 	// TODO: make range > maxInt32 reachable
-	return min + uint32(rand.Int31n(int32(max-min+uint32(1))))
+	return min + uint32(r.rnd.Int31n(int32(max-min+uint32(1))))
 }
@@ -7,7 +7,7 @@ import (
 )
 
 func TestGenerators_Characters(t *testing.T) {
-	gen, err := newGenerator("")
+	gen, err := newGenerator("", 0)
 	if assert.NoError(t, err) {
 		opts := &simpleOpts{}
 		opts.name = "characters"
@@ -31,7 +31,7 @@ func TestGenerators_Characters(t *testing.T) {
 }
 
 func TestGeneratorsBool(t *testing.T) {
-	gen, err := newGenerator("")
+	gen, err := newGenerator("", 0)
 	if assert.NoError(t, err) {
 		opts := &simpleOpts{}
 		opts.name = "bool"
@@ -55,3 +55,19 @@ func TestGeneratorsBool(t *testing.T) {
 		}
 	}
 }
+
+func TestRandomGenerator_Weighted(t *testing.T) {
+	r := newRandomGenerator(randomOpts{Seed: 1})
+
+	for i := 0; i < 64; i++ {
+		index, err := r.Weighted([]float64{0, 1, 0})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, index)
+		}
+	}
+
+	_, err := r.Weighted([]float64{0, 0})
+	assert.Error(t, err)
+	_, err = r.Weighted(nil)
+	assert.Error(t, err)
+}
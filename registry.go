@@ -0,0 +1,66 @@
+package stubs
+
+import "strings"
+
+// customGenerators is the default registry of custom value generators, shared by all generators
+var customGenerators map[string]ValueGenerator
+
+// RegisterGenerator registers a custom value generator in the default registry, shared by all generators.
+//
+// The value generator is then selected by its name or any of its aliases, either from the x-datagen extension
+// (e.g. x-datagen: {name: iban}), or inferred from the format, field name, title or description of a descriptor.
+// A custom value generator replaces any built-in generator with the same name.
+//
+// Like RegisterAltGenNames, this is meant to be called at initialization time, before any value is generated.
+func RegisterGenerator(name string, fn ValueGenerator, aliases ...string) {
+	if customGenerators == nil {
+		customGenerators = make(map[string]ValueGenerator, 10)
+	}
+	key := strings.ToLower(name)
+	customGenerators[key] = fn
+	RegisterAltGenNames(key, append([]string{key}, lowerAll(aliases)...)...)
+}
+
+// RegisterGenerator registers a custom value generator for this generator only.
+//
+// The value generator is selected by its name or any of its aliases from the x-datagen extension or
+// from the field name of a descriptor. It prevails over the default registry and the built-in generators.
+//
+// Registration is safe for concurrent use: it applies to values generated after the registration.
+func (s *Generator) RegisterGenerator(name string, fn ValueGenerator, aliases ...string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// generators in use hold the previous registry, which is never modified
+	custom := make(map[string]ValueGenerator, len(s.custom)+len(aliases)+1)
+	for k, v := range s.custom {
+		custom[k] = v
+	}
+	custom[strings.ToLower(name)] = fn
+	for _, alias := range lowerAll(aliases) {
+		custom[alias] = fn
+	}
+	s.custom = custom
+}
+
+// lookup yields the value generator registered for a name, looking in turn at the registry of the Generator,
+// the default registry and the built-in generators. It returns the name under which it is registered.
+func (g *generators) lookup(name string) (string, ValueGenerator, bool) {
+	if gen, ok := g.custom[strings.ToLower(name)]; ok {
+		return strings.ToLower(name), gen, true
+	}
+	key := normalizeGeneratorName(name)
+	if gen, ok := customGenerators[key]; ok {
+		return key, gen, true
+	}
+	gen, ok := g.gens[key]
+	return key, gen, ok
+}
+
+func lowerAll(names []string) []string {
+	res := make([]string, 0, len(names))
+	for _, name := range names {
+		res = append(res, strings.ToLower(name))
+	}
+	return res
+}
@@ -0,0 +1,87 @@
+package stubs
+
+import (
+	"fmt"
+
+	"github.com/go-openapi/spec"
+)
+
+// schemaContext carries the state needed to resolve and follow $ref while generating nested schemas.
+//
+// A context is shared by all the nested schemas until a $ref is followed: following a $ref derives a new context.
+type schemaContext struct {
+	root      *spec.Swagger // root document to resolve $ref against
+	maxDepth  int           // maximum number of times a recursive $ref may be followed
+	refs      []string      // chain of $ref followed so far
+	recursion int           // number of recursive $ref followed so far
+	entropy   *randomGenerator
+}
+
+// newSchemaContext builds the context to generate a schema from the root document
+func newSchemaContext(root *spec.Swagger, maxDepth int, entropy *randomGenerator) *schemaContext {
+	if maxDepth <= 0 {
+		maxDepth = StubsDefaultMaxDepth
+	}
+	return &schemaContext{
+		root:     root,
+		maxDepth: maxDepth,
+		entropy:  entropy,
+	}
+}
+
+// exhausted tells if the depth budget for recursive schemas is spent.
+//
+// When exhausted, nested values are kept to the minimum required by validations:
+// optional properties are omitted and arrays are as short as possible.
+func (c *schemaContext) exhausted() bool {
+	return c != nil && c.recursion >= c.maxDepth
+}
+
+// follow derives a new context after following a $ref.
+//
+// When the $ref has already been followed in the current chain, a cycle is detected and the depth budget is consumed.
+func (c *schemaContext) follow(ref string) (*schemaContext, error) {
+	if c == nil {
+		c = newSchemaContext(nil, 0, nil)
+	}
+	next := &schemaContext{
+		root:      c.root,
+		maxDepth:  c.maxDepth,
+		refs:      make([]string, len(c.refs), len(c.refs)+1),
+		recursion: c.recursion,
+		entropy:   c.entropy,
+	}
+	copy(next.refs, c.refs)
+	for _, followed := range c.refs {
+		if followed == ref {
+			next.recursion++
+			debugLog("recursive $ref detected: %s (depth: %d)", ref, next.recursion)
+			break
+		}
+	}
+	if next.recursion > next.maxDepth {
+		return nil, fmt.Errorf("recursive $ref %q cannot be generated within max depth %d", ref, next.maxDepth)
+	}
+	next.refs = append(next.refs, ref)
+	return next, nil
+}
+
+// resolve follows all $ref in a schema until a schema without $ref is found
+func (c *schemaContext) resolve(schema *spec.Schema) (*spec.Schema, *schemaContext, error) {
+	ctx := c
+	for schema.Ref.String() != "" {
+		ref := schema.Ref.String()
+		if ctx == nil || ctx.root == nil {
+			return nil, nil, fmt.Errorf("cannot resolve $ref %q without a root document", ref)
+		}
+		resolved, err := spec.ResolveRef(ctx.root, &schema.Ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ctx, err = ctx.follow(ref); err != nil {
+			return nil, nil, err
+		}
+		schema = resolved
+	}
+	return schema, ctx, nil
+}
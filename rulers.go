@@ -1,8 +1,6 @@
 package stubs
 
 import (
-	"strings"
-
 	"github.com/go-openapi/spec"
 )
 
@@ -100,6 +98,12 @@ func (f *formatRuler) Decide() basicGeneratorOpts {
 	if f == nil {
 		return nil
 	}
+	if name, found := generatorForFormat(f.Format); found {
+		g := &genOpts{}
+		g.name = name
+		debugLog("formatRuler decides: %s", g.name)
+		return g
+	}
 	debugLog("formatRuler checks for %s given format %s", normalizeGeneratorName(f.Format), f.Format)
 	if _, found := generatorAliases[normalizeGeneratorName(f.Format)]; found {
 		g := &genOpts{}
@@ -149,11 +153,15 @@ func (p *patternRuler) Decide() basicGeneratorOpts {
 	return g
 }
 
-// fuzzyRuler infers options from title and description
+// fuzzyRuler infers options from title and description.
+//
+// Only generators producing values of the type and format of the descriptor are elected.
 type fuzzyRuler struct {
 	ruler
 	Title       string
 	Description string
+	Type        string
+	Format      string
 }
 
 // newFuzzyRuler instantiate a new ruler for fuzzy-based decisions
@@ -165,11 +173,15 @@ func newFuzzyRulerFor(v interface{}) *fuzzyRuler {
 	case *spec.Parameter:
 		f.Title = tv.Name
 		f.Description = tv.Description
+		f.Type, f.Format = tv.Type, tv.Format
 	case *spec.Header:
 		f.Description = tv.Description
+		f.Type, f.Format = tv.Type, tv.Format
 	case *spec.Schema:
 		f.Title = tv.Title
 		f.Description = tv.Description
+		f.Type, _ = fuzzyTypeOf(tv.Type...)
+		f.Format = tv.Format
 	default:
 		return nil
 	}
@@ -181,72 +193,124 @@ func newFuzzyRulerFor(v interface{}) *fuzzyRuler {
 
 // Decide takes a decision according to probable matches in Title and Description
 func (f *fuzzyRuler) Decide() basicGeneratorOpts {
+	decision, _, _ := f.decideWithScore()
+	return decision
+}
+
+// decideWithScore takes a decision like Decide, and yields the best guess with its score, even when not confident enough
+func (f *fuzzyRuler) decideWithScore() (basicGeneratorOpts, string, float64) {
 	debugLog("fuzzyRuler.Decide()")
 	if f == nil || (f.Title == "" && f.Description == "") {
-		return nil
+		return nil, "", 0
 	}
-	scoreTitle := scoreAgainstProposals(f.Title, generatorAliases)
-	scoreDescription := scoreAgainstProposals(f.Description, generatorAliases)
-	key := mergeAndElectProposal(scoreTitle, scoreDescription)
-	if key == "" {
-		return nil
+	key, score := f.elect()
+	if key == "" || score < minFuzzyConfidence {
+		debugLog("fuzzyRuler has no confident decision: best is %q with score %.2f", key, score)
+		return nil, key, score
 	}
 	g := &genOpts{}
 	g.name = key
-	return g
+	debugLog("fuzzyRuler decides: %s (score %.2f)", g.name, score)
+	return g, key, score
+}
+
+// elect yields the best generator for the title and description, with its score
+func (f *fuzzyRuler) elect() (string, float64) {
+	scoreTitle := f.retainCompatible(scoreAgainstProposals(f.Title, generatorAliases))
+	scoreDescription := f.retainCompatible(scoreAgainstProposals(f.Description, generatorAliases))
+	return mergeAndElectProposal(
+		weighScores(scoreTitle, fuzzyTitleWeight),
+		weighScores(scoreDescription, fuzzyDescriptionWeight),
+	)
+}
+
+// retainCompatible removes from scores the generators which do not produce values of the expected type and format
+func (f *fuzzyRuler) retainCompatible(scores map[string]float64) map[string]float64 {
+	for key := range scores {
+		if !compatibleGenerator(key, f.Type, f.Format) {
+			delete(scores, key)
+		}
+	}
+	return scores
 }
 
 // itemsRuler infers options from items type
 type itemsRuler struct {
 	ruler
-	byType    *typeRuler
-	byFormat  *formatRuler
-	byPattern *patternRuler
-	Child     *itemsRuler
+	Items *spec.Items
 	// TODO: keep CollectionFormat to allow for ready-to-parse fixtures (option)
 }
 
 // newItemsRuler instantiate a new ruler for items-based decisions
-// TODO: inference could be helped with default or example values
 func newItemsRulerFor(v interface{}) *itemsRuler {
 	i := &itemsRuler{}
 	switch tv := v.(type) {
 	case *spec.Items:
 		// simple items
-		if tv.Items != nil {
-			i.Child = newItemsRulerFor(tv.Items)
-		} else {
-			i.byType = newTypeRulerFor(tv)
-			i.byFormat = newFormatRulerFor(tv)
-			i.byPattern = newPatternRulerFor(tv)
+		if tv == nil {
+			return nil
 		}
+		i.Items = tv
 	default:
 		return nil
 	}
 	return i
 }
 
-// Decide takes a decision for items
+// Decide takes a decision for items: the value is an array.
+//
+// Decisions regarding the members of the array are taken by the rules registered for items.
 func (i *itemsRuler) Decide() basicGeneratorOpts {
 	debugLog("itemsRuler.Decide()")
-	if i == nil {
+	if i == nil || i.Items == nil {
+		return nil
+	}
+	g := &genOpts{}
+	g.name = "array"
+	debugLog("itemsRuler decides: %s", g.name)
+	return g
+}
+
+// enumRuler infers options from enum
+type enumRuler struct {
+	ruler
+	Enum []interface{}
+}
+
+// newEnumRuler instantiate a new ruler for enum-based decisions
+func newEnumRulerFor(v interface{}) *enumRuler {
+	e := &enumRuler{}
+	switch tv := v.(type) {
+	case *spec.Parameter:
+		e.Enum = tv.Enum
+	case *spec.Header:
+		e.Enum = tv.Enum
+	case *spec.Items:
+		e.Enum = tv.Enum
+	case *spec.Schema:
+		e.Enum = tv.Enum
+	default:
 		return nil
 	}
-	current := i
-	for current.Child != nil {
-		current = current.Child
+	if len(e.Enum) == 0 {
+		return nil
 	}
-	decisions := []basicGeneratorOpts{
-		current.byType.Decide(),
-		current.byFormat.Decide(),
-		current.byPattern.Decide(),
+	return e
+}
+
+// Decide takes a decision according to Enum: values are drawn from the enum, regardless of other decisions
+func (e *enumRuler) Decide() basicGeneratorOpts {
+	debugLog("enumRuler.Decide()")
+	if e == nil || len(e.Enum) == 0 {
+		return nil
 	}
 	g := &genOpts{}
-	g.merge(decisions)
+	g.name = "enum"
+	debugLog("enumRuler decides: %s", g.name)
 	return g
 }
 
-// schema infers options for schemas
+// schemaRuler infers options for composite schemas
 type schemaRuler struct {
 	ruler
 	Schema *spec.Schema
@@ -254,46 +318,32 @@ type schemaRuler struct {
 
 // newSchemaRuler instantiate a new ruler for schema-based decisions
 func newSchemaRulerFor(v interface{}) *schemaRuler {
-	// TODO
-	i := &schemaRuler{}
-	return i
+	s := &schemaRuler{}
+	switch tv := v.(type) {
+	case *spec.Schema:
+		s.Schema = tv
+	default:
+		return nil
+	}
+	return s
 }
 
+// Decide takes a decision according to the structure of the schema
 func (s *schemaRuler) Decide() basicGeneratorOpts {
 	debugLog("schemaRuler.Decide()")
-	// TODO
-	g := &genOpts{}
-	return g
-}
-
-// scoreAgainstProposals computes the similarity score for a text against the proposed aliased keys
-func scoreAgainstProposals(text string, proposals map[string]string) (score map[string]float64) {
-	score = make(map[string]float64, 300)
-	// trivial implem for demo purpose
-	for alias, generator := range proposals {
-		if strings.Contains(text, alias) {
-			// TODO: here should be a better appreciation on how close we are from the key
-			score[generator] = score[generator] + 1
-		} else {
-			score[generator] = 0
-		}
-	}
-	return
-}
-
-// mergeAndElectProposal elects the best proposal from a slice of scoring maps
-func mergeAndElectProposal(scores ...map[string]float64) (key string) {
-	var merger = make(map[string]float64, 300)
-	for _, scoringMap := range scores {
-		for k, score := range scoringMap {
-			merger[k] = merger[k] + score
-		}
+	if s == nil || s.Schema == nil {
+		return nil
 	}
-	best := float64(0)
-	for k, s := range merger {
-		if s > best {
-			key = k
-		}
+	g := &genOpts{}
+	switch {
+	case s.Schema.Type.Contains("array"):
+		g.name = "array"
+	case len(s.Schema.Type) == 0 || s.Schema.Type.Contains("object"):
+		// a schema without type is generated as an object, unless some other rule decides otherwise
+		g.name = "object"
+	default:
+		return nil
 	}
-	return
+	debugLog("schemaRuler decides: %s", g.name)
+	return g
 }
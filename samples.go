@@ -0,0 +1,334 @@
+package stubs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-openapi/spec"
+)
+
+// xExample is the extension which carries an example for descriptors without an example field
+const xExample = "x-example"
+
+const (
+	// OriginRandom is the origin of samples generated at random
+	OriginRandom = "random"
+	// OriginDefault is the origin of samples built from a default value
+	OriginDefault = "default"
+	// OriginExample is the origin of samples built from an example value
+	OriginExample = "example"
+	// OriginEdgeCase is the origin of samples built from an edge case
+	OriginEdgeCase = "edgeCase"
+	// OriginValidationCheck is the origin of samples generated to fail some validations on purpose
+	OriginValidationCheck = "validationCheck"
+)
+
+// Sample is a generated value, labelled with the way it has been produced
+type Sample struct {
+	// Value is the generated value
+	Value interface{}
+	// Valid tells if the value is expected to pass all validations
+	Valid bool
+	// Violations are the validations the value is expected to fail.
+	//
+	// The Invalid flag stands for failures without a dedicated mode, such as a literal edge case which
+	// does not match the format of the descriptor.
+	Violations StubMode
+	// Origin tells how the value has been produced
+	Origin string
+}
+
+// exampledOpts is implemented by generation options which may carry a default value or an example
+type exampledOpts interface {
+	// DefaultValue of the descriptor, returns value, defined
+	DefaultValue() (interface{}, bool)
+
+	// ExampleValue of the descriptor, returns value, defined
+	ExampleValue() (interface{}, bool)
+}
+
+// injection is a value injected at some path, instead of a generated value
+type injection struct {
+	value interface{}
+	tilt  StubMode // when not Valid, the injected value is tilted to fail these validations
+}
+
+// exampleOf yields the example of a descriptor, or the value of its x-example extension
+func exampleOf(example interface{}, extensions spec.Extensions) interface{} {
+	if example != nil {
+		return example
+	}
+	return extensions[xExample]
+}
+
+// inject yields an injected value, converted according to the type of the descriptor and possibly tilted
+func (g *generators) inject(injected injection, opts GeneratorOpts) (interface{}, error) {
+	value, err := g.typedValue(injected.value, opts.Type(), opts.Format())
+	if err != nil {
+		return nil, err
+	}
+	if injected.tilt == Valid {
+		return value, nil
+	}
+	return g.tilt(value, opts, injected.tilt)
+}
+
+// GenerateSamples generates a set of samples for a descriptor.
+//
+// The set of samples is determined by the args of the generator and the x-datagen extension of the descriptor,
+// which prevail over the general args:
+//   - valid: the number of valid random samples, with "count". Defaults to 1, or 0 when invalid is specified.
+//   - invalid: the number of invalid random samples, with "count". Defaults to 1 when specified.
+//     Each invalid sample fails a validation chosen at random.
+//   - withEdgeCase: add a sample for each edge case of the descriptor or its nested descriptors. With true or "standard",
+//     edge cases are drawn from a standard catalogue for each type and format (see standardEdgeCasesFor).
+//     Other values are literal edge values, converted to the type of the descriptor.
+//     Edge case samples may be valid or not, and are labelled accordingly.
+//   - withDefault: add a sample for each default value found in the descriptor or its nested descriptors.
+//     Nested defaults are injected in a value generated at random.
+//   - withExample: add a sample for each example found in the descriptor or its nested descriptors.
+//     Examples are assumed to be valid.
+//   - tiltDefault, tiltExample: specified as args of withDefault or withExample, add invalid samples
+//     by tilting default values or examples. The number of tilted samples for each value is set by "count".
+//   - withValidationCheck, withAllValidationChecks: add samples checking validations (see GenValidationChecks)
+func (s *Generator) GenerateSamples(key string, descriptor interface{}) ([]Sample, error) {
+	generator, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer s.release(generator)
+	gopts, err := s.genOptsFor(generator, key, descriptor)
+	if err != nil || gopts == nil {
+		return nil, err
+	}
+	args, err := s.sampleArgs(gopts)
+	if err != nil {
+		return nil, err
+	}
+
+	validCount := 1
+	if args.Invalid.Enabled {
+		validCount = 0
+	}
+	if args.Valid.Enabled {
+		validCount = args.Valid.Count(1)
+	}
+	invalidCount := 0
+	if args.Invalid.Enabled {
+		invalidCount = args.Invalid.Count(1)
+	}
+
+	samples := make([]Sample, 0, validCount+invalidCount)
+	for i := 0; i < validCount; i++ {
+		// options are built again for each sample, so samples may exhibit different subtypes
+		sopts, err := s.genOptsFor(generator, key, descriptor)
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.genSample(generator, key, descriptor, sopts, nil, nil, Valid)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, Sample{Value: value, Valid: true, Origin: OriginRandom})
+	}
+
+	for i := 0; i < invalidCount; i++ {
+		sopts, err := s.genOptsFor(generator, key, descriptor)
+		if err != nil {
+			return nil, err
+		}
+		sample, err := s.genInvalidSample(generator, key, descriptor, sopts)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+
+	edgeSamples, err := s.edgeCaseSamples(generator, key, descriptor, gopts, args.WithEdgeCase)
+	if err != nil {
+		return nil, err
+	}
+	samples = append(samples, edgeSamples...)
+
+	tiltDefault := isTrue(args.WithDefault.Args["tiltDefault"]) || isTrue(args.WithExample.Args["tiltDefault"])
+	if args.WithDefault.Enabled || tiltDefault {
+		defaultSamples, err := s.injectedSamples(generator, key, descriptor, OriginDefault, args.WithDefault.Enabled, tiltDefault, args.WithDefault.Count(1))
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, defaultSamples...)
+	}
+
+	tiltExample := isTrue(args.WithExample.Args["tiltExample"]) || isTrue(args.WithDefault.Args["tiltExample"])
+	if args.WithExample.Enabled || tiltExample {
+		exampleSamples, err := s.injectedSamples(generator, key, descriptor, OriginExample, args.WithExample.Enabled, tiltExample, args.WithExample.Count(1))
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, exampleSamples...)
+	}
+
+	if args.WithValidationCheck.Enabled || args.WithAllValidationChecks.Enabled {
+		check, count := args.WithValidationCheck, 0
+		if args.WithAllValidationChecks.Enabled {
+			check, count = args.WithAllValidationChecks, StubsDefaultValidationChecks
+		}
+		checkSamples, err := s.validationCheckSamples(generator, key, descriptor, args.WithAllValidationChecks.Enabled, check.Count(count))
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, checkSamples...)
+	}
+	return samples, nil
+}
+
+// sampleArgs merges the general args of the generator with the args of the descriptor
+func (s *Generator) sampleArgs(gopts GeneratorOpts) (*argTags, error) {
+	args := argTags{}
+	if s.Args != nil {
+		if err := decodeArgs(s.Args, &args); err != nil {
+			return nil, err
+		}
+	}
+	local := gopts.Args()
+	for _, arg := range []struct{ general, local *boolOrMap }{
+		{&args.Valid, &local.Valid},
+		{&args.Invalid, &local.Invalid},
+		{&args.WithDefault, &local.WithDefault},
+		{&args.WithExample, &local.WithExample},
+		{&args.WithTilting, &local.WithTilting},
+		{&args.WithValidationCheck, &local.WithValidationCheck},
+		{&args.WithAllValidationChecks, &local.WithAllValidationChecks},
+	} {
+		if arg.local.Enabled {
+			*arg.general = *arg.local
+		}
+	}
+	if local.WithEdgeCase.Enabled {
+		args.WithEdgeCase = local.WithEdgeCase
+	}
+	return &args, nil
+}
+
+// genSample generates a single sample, with some modes overridden or some values injected, by path.
+//
+// Paths refer to the generation options passed, which must be those used to find the paths: building options
+// again may pick other subtypes for polymorphic types. The returned error wraps errNotApplied when
+// no value has been generated at some of the paths.
+//
+// In Verify mode, the sample is checked to exhibit the expected violations.
+func (s *Generator) genSample(generator *generators, key string, descriptor interface{}, gopts GeneratorOpts, overrides map[string]StubMode, injections map[string]injection, expected StubMode) (interface{}, error) {
+	generator.overrides, generator.injections = overrides, injections
+	generator.visited = make(map[string]bool, len(overrides)+len(injections))
+	defer func() { generator.overrides, generator.injections, generator.visited = nil, nil, nil }()
+
+	value, err := s.verified(generator, key, descriptor, gopts, expected)
+	if err != nil {
+		return nil, err
+	}
+	for path := range overrides {
+		if !generator.visited[path] {
+			return nil, fmt.Errorf("%w: %q", errNotApplied, path)
+		}
+	}
+	for path := range injections {
+		if !generator.visited[path] {
+			return nil, fmt.Errorf("%w: %q", errNotApplied, path)
+		}
+	}
+	return value, nil
+}
+
+// injectedSamples builds samples from the default values or the examples found in a descriptor and its nested descriptors.
+//
+// Values found in nested descriptors are injected in a value generated at random, from the options where
+// they have been found. With tilt enabled, count invalid samples are added for each value, tilted on a validation chosen at random.
+func (s *Generator) injectedSamples(generator *generators, key string, descriptor interface{}, origin string, withValues, tilt bool, count int) ([]Sample, error) {
+	gopts, err := s.genOptsFor(generator, key, descriptor)
+	if err != nil || gopts == nil {
+		return nil, err
+	}
+
+	type found struct {
+		path  string
+		value interface{}
+		modes StubMode
+	}
+	values := make([]found, 0, 10)
+	walkOpts(gopts, func(path, _ string, opts GeneratorOpts) {
+		e, ok := opts.(exampledOpts)
+		if !ok {
+			return
+		}
+		value, defined := e.ExampleValue()
+		if origin == OriginDefault {
+			value, defined = e.DefaultValue()
+		}
+		if !defined {
+			return
+		}
+		var modes StubMode
+		for _, mode := range tiltableModes {
+			modes |= validationModes(opts) & mode
+		}
+		values = append(values, found{path: path, value: value, modes: modes})
+	})
+	debugLog("%d %s values found for %q", len(values), origin, key)
+
+	samples := make([]Sample, 0, len(values))
+	for _, v := range values {
+		if withValues {
+			value, err := s.genSample(generator, key, descriptor, gopts, nil, map[string]injection{v.path: {value: v.value}}, Valid)
+			if errors.Is(err, errNotApplied) {
+				debugLog("%s value %v at %q cannot be injected", origin, v.value, v.path)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, Sample{Value: value, Valid: true, Origin: origin})
+		}
+		if !tilt || v.modes == Valid {
+			continue
+		}
+		for i := 0; i < count; i++ {
+			mode := generator.pickMode(v.modes)
+			value, err := s.genSample(generator, key, descriptor, gopts, nil, map[string]injection{v.path: {value: v.value, tilt: mode}}, mode)
+			if errors.Is(err, ErrNoInvalid) || errors.Is(err, errNotApplied) {
+				debugLog("%s value %v at %q cannot be tilted with mode %d", origin, v.value, v.path, mode)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, Sample{Value: value, Violations: mode, Origin: origin})
+		}
+	}
+	return samples, nil
+}
+
+func isTrue(arg string) bool {
+	b, _ := strconv.ParseBool(arg)
+	return b
+}
+
+// genInvalidSample generates a sample failing a validation chosen at random among the validations declared
+// by some generation options
+func (s *Generator) genInvalidSample(generator *generators, key string, descriptor interface{}, gopts GeneratorOpts) (Sample, error) {
+	checks := generator.validationChecks(gopts)
+	order := generator.entropy.Perm(len(checks))
+	for _, i := range order {
+		check := checks[i]
+		value, err := s.genSample(generator, key, descriptor, gopts, map[string]StubMode{check.path: check.mode}, nil, check.mode)
+		if errors.Is(err, ErrNoInvalid) || errors.Is(err, errNotApplied) {
+			continue
+		}
+		if err != nil {
+			return Sample{}, err
+		}
+		return Sample{Value: value, Violations: check.mode, Origin: OriginRandom}, nil
+	}
+	debugLog("no validation may be failed for %q", key)
+	return Sample{}, ErrNoInvalid
+}
@@ -1,12 +1,72 @@
 package stubs
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+
 	"github.com/go-openapi/spec"
 	"github.com/go-openapi/swag"
 )
 
-func schemaGenOpts(key string, required bool, schema *spec.Schema) (*schemaOpts, error) {
+// rootSchemaGenOpts generates stubs for the root schema of a value.
+//
+// Unlike nested schemas, a root schema without $ref is assumed to be the definition named by key, if any.
+func rootSchemaGenOpts(key string, schema *spec.Schema, ctx *schemaContext) (*schemaOpts, error) {
+	var definition string
+	if ctx.hasDefinition(key) {
+		definition = key
+	}
+	return definitionGenOpts(key, definition, true, schema, ctx)
+}
+
+// schemaGenOpts generates stubs for a schema.
+//
+// $ref are resolved against the root document in the schema context.
+func schemaGenOpts(key string, required bool, schema *spec.Schema, ctx *schemaContext) (*schemaOpts, error) {
+	return definitionGenOpts(key, "", required, schema, ctx)
+}
+
+// definitionGenOpts generates stubs for a schema, which stands for some definition when not resolved from a $ref
+func definitionGenOpts(key, definition string, required bool, schema *spec.Schema, ctx *schemaContext) (*schemaOpts, error) {
 	var gopts genOpts
+	resolved, rctx, err := ctx.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// name of the definition being generated, if known
+	if rctx != ctx {
+		definition = rctx.definitionName()
+	}
+
+	var discriminatorValue string
+	if resolved.Discriminator != "" {
+		// polymorphic type: pick one of the subtypes
+		if resolved, rctx, discriminatorValue, err = ctx.pickSubtype(definition, resolved, rctx); err != nil {
+			return nil, err
+		}
+	}
+	schema, ctx = resolved, rctx
+
+	var patterns []*regexp.Regexp
+	if len(schema.AllOf) > 0 {
+		var extra []string
+		if schema, ctx, extra, err = ctx.mergeAllOf(key, schema); err != nil {
+			return nil, err
+		}
+		for _, pattern := range extra {
+			re, err := compilePattern(pattern)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, re)
+		}
+		if schema.Discriminator != "" && discriminatorValue == "" {
+			// a subtype generated directly
+			discriminatorValue = discriminatorValueOf(definition, resolved)
+		}
+	}
 	if err := gopts.ExtOverride(schema.Extensions); err != nil {
 		debugLog("extension error on %s: %v", gopts.Name(), err)
 		return nil, err
@@ -18,22 +78,84 @@ func schemaGenOpts(key string, required bool, schema *spec.Schema) (*schemaOpts,
 		gopts.rules = make([]ruler, 0, 50)
 
 		gopts.rules = append(gopts.rules, newSchemaRulerFor(schema))
+
+		gopts.rules = append(gopts.rules, newTypeRulerFor(schema))
+
+		if schema.Format != "" {
+			gopts.rules = append(gopts.rules, newFormatRulerFor(schema))
+		}
+
+		// fuzzy matches are more specific than formats
+		if _, ok := fuzzyTypeOf(schema.Type...); ok {
+			gopts.rules = append(gopts.rules, newFuzzyRulerFor(schema))
+		}
+
+		if schema.Pattern != "" {
+			gopts.rules = append(gopts.rules, newPatternRulerFor(schema))
+		}
+
+		if len(schema.Enum) > 0 {
+			gopts.rules = append(gopts.rules, newEnumRulerFor(schema))
+		}
 	}
 	return &schemaOpts{
 		genOpts:   gopts,
 		fieldName: key,
 		schema:    schema,
 		required:  required,
+		ctx:       ctx,
+		patterns:  patterns,
+
+		discriminatorValue: discriminatorValue,
 	}, nil
 }
 
 type schemaOpts struct {
 	genOpts
-	defaultSeeder
 	schema *spec.Schema
 
 	fieldName string
 	required  bool
+	ctx       *schemaContext
+	patterns  []*regexp.Regexp // additional patterns from allOf members
+
+	discriminatorValue string // the value of the discriminator property, when a subtype has been picked
+
+	nested map[string][]GeneratorOpts // nested options already built, by kind
+}
+
+// memoized yields the nested options of some kind, built only once.
+//
+// Building nested options may pick subtypes at random: options are built once, so every walk through
+// the options of a schema sees the same subtypes.
+func (s *schemaOpts) memoized(kind string, build func() ([]GeneratorOpts, error)) ([]GeneratorOpts, error) {
+	if nested, ok := s.nested[kind]; ok {
+		return nested, nil
+	}
+	nested, err := build()
+	if err != nil {
+		return nil, err
+	}
+	if s.nested == nil {
+		s.nested = make(map[string][]GeneratorOpts, 2)
+	}
+	s.nested[kind] = nested
+	return nested, nil
+}
+
+// memoizedOne yields a single nested option of some kind, built only once
+func (s *schemaOpts) memoizedOne(kind string, build func() (GeneratorOpts, error)) (GeneratorOpts, error) {
+	nested, err := s.memoized(kind, func() ([]GeneratorOpts, error) {
+		opts, err := build()
+		if err != nil || opts == nil {
+			return nil, err
+		}
+		return []GeneratorOpts{opts}, nil
+	})
+	if err != nil || len(nested) == 0 {
+		return nil, err
+	}
+	return nested[0], nil
 }
 
 func (s *schemaOpts) FieldName() string {
@@ -84,8 +206,154 @@ func (s *schemaOpts) Format() string {
 	return s.schema.Format
 }
 func (s *schemaOpts) Items() (GeneratorOpts, error) {
-	return schemaGenOpts(s.fieldName+".items", false, s.schema.Items.Schema)
+	return s.memoizedOne("items", func() (GeneratorOpts, error) {
+		if s.schema.Items == nil || s.schema.Items.Schema == nil {
+			// unspecified items: any value is valid
+			return schemaGenOpts(s.fieldName+".items", true, &spec.Schema{}, s.ctx)
+		}
+		return schemaGenOpts(s.fieldName+".items", true, s.schema.Items.Schema, s.ctx)
+	})
 }
 func (s *schemaOpts) Required() bool {
 	return s.required
 }
+func (s *schemaOpts) DefaultValue() (interface{}, bool) {
+	return s.schema.Default, s.schema.Default != nil
+}
+func (s *schemaOpts) ExampleValue() (interface{}, bool) {
+	example := exampleOf(s.schema.Example, s.schema.Extensions)
+	return example, example != nil
+}
+
+// TupleItems yields the generation options for positional items, when items are specified as a tuple.
+//
+// It returns nil when items are not a tuple.
+func (s *schemaOpts) TupleItems() ([]GeneratorOpts, error) {
+	if s.schema.Items == nil || len(s.schema.Items.Schemas) == 0 {
+		return nil, nil
+	}
+	return s.memoized("tupleItems", func() ([]GeneratorOpts, error) {
+		tuple := make([]GeneratorOpts, 0, len(s.schema.Items.Schemas))
+		for i := range s.schema.Items.Schemas {
+			iopts, err := schemaGenOpts(fmt.Sprintf("%s.items.%d", s.fieldName, i), true, &s.schema.Items.Schemas[i], s.ctx)
+			if err != nil {
+				return nil, err
+			}
+			tuple = append(tuple, iopts)
+		}
+		return tuple, nil
+	})
+}
+
+// AdditionalItems yields the generation options for items beyond a tuple.
+//
+// It returns nil when additional items are not allowed.
+func (s *schemaOpts) AdditionalItems() (GeneratorOpts, error) {
+	additional := s.schema.AdditionalItems
+	if additional != nil && !additional.Allows {
+		return nil, nil
+	}
+	return s.memoizedOne("additionalItems", func() (GeneratorOpts, error) {
+		if additional == nil || additional.Schema == nil {
+			return schemaGenOpts(s.fieldName+".additionalItems", true, &spec.Schema{}, s.ctx)
+		}
+		return schemaGenOpts(s.fieldName+".additionalItems", true, additional.Schema, s.ctx)
+	})
+}
+
+// Properties yields the generation options for all properties of an object schema.
+//
+// Properties are sorted by name, so the generation sequence is repeatable.
+// When the depth budget for recursive schemas is exhausted, only required properties are yielded.
+func (s *schemaOpts) Properties() ([]GeneratorOpts, error) {
+	return s.memoized("properties", func() ([]GeneratorOpts, error) {
+		names := make([]string, 0, len(s.schema.Properties))
+		for name := range s.schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		props := make([]GeneratorOpts, 0, len(names))
+		for _, name := range names {
+			required := swag.ContainsStrings(s.schema.Required, name)
+			if !required && s.ctx.exhausted() {
+				debugLog("max depth reached: skipping optional property %q", name)
+				continue
+			}
+			prop := s.schema.Properties[name]
+			popts, err := schemaGenOpts(name, required, &prop, s.ctx)
+			if err != nil {
+				return nil, err
+			}
+			props = append(props, popts)
+		}
+		return props, nil
+	})
+}
+
+// AdditionalProperties yields the generation options for additional properties.
+//
+// It returns nil when additional properties are not explicitly allowed.
+func (s *schemaOpts) AdditionalProperties() (GeneratorOpts, error) {
+	additional := s.schema.AdditionalProperties
+	if additional == nil || !additional.Allows {
+		return nil, nil
+	}
+	return s.memoizedOne("additionalProperties", func() (GeneratorOpts, error) {
+		if additional.Schema == nil {
+			// any value is valid
+			return schemaGenOpts(s.fieldName+".additionalProperties", true, spec.StringProperty(), s.ctx)
+		}
+		return schemaGenOpts(s.fieldName+".additionalProperties", true, additional.Schema, s.ctx)
+	})
+}
+
+// PatternProperties yields the patterns for property keys and the generation options for their values.
+//
+// Patterns are sorted, so the generation sequence is repeatable.
+func (s *schemaOpts) PatternProperties() ([]string, []GeneratorOpts, error) {
+	patterns := make([]string, 0, len(s.schema.PatternProperties))
+	for pattern := range s.schema.PatternProperties {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	props, err := s.memoized("patternProperties", func() ([]GeneratorOpts, error) {
+		props := make([]GeneratorOpts, 0, len(patterns))
+		for _, pattern := range patterns {
+			prop := s.schema.PatternProperties[pattern]
+			popts, err := schemaGenOpts(pattern, true, &prop, s.ctx)
+			if err != nil {
+				return nil, err
+			}
+			props = append(props, popts)
+		}
+		return props, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return patterns, props, nil
+}
+
+func (s *schemaOpts) MinProperties() (int64, bool) {
+	return swag.Int64Value(s.schema.MinProperties), s.schema.MinProperties != nil
+}
+func (s *schemaOpts) MaxProperties() (int64, bool) {
+	return swag.Int64Value(s.schema.MaxProperties), s.schema.MaxProperties != nil
+}
+
+// Exhausted tells if the depth budget for recursive schemas is spent
+func (s *schemaOpts) Exhausted() bool {
+	return s.ctx.exhausted()
+}
+
+// ExtraPatterns yields the patterns a value must match, besides the one returned by Pattern()
+func (s *schemaOpts) ExtraPatterns() []*regexp.Regexp {
+	return s.patterns
+}
+
+// Discriminator yields the discriminator property and the value identifying the picked subtype
+func (s *schemaOpts) Discriminator() (string, string, bool) {
+	return s.schema.Discriminator, s.discriminatorValue, s.schema.Discriminator != "" && s.discriminatorValue != ""
+}
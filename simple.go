@@ -7,12 +7,12 @@ import (
 
 type simpleOpts struct {
 	genOpts
-	defaultSeeder
 	spec.CommonValidations
 	spec.SimpleSchema
 
 	fieldName string
 	required  bool
+	example   interface{}
 }
 
 func (g *simpleOpts) FieldName() string {
@@ -59,10 +59,15 @@ func (g *simpleOpts) Format() string {
 	return g.SimpleSchema.Format
 }
 
-// TODO: remove this, rulers replace it now
 func (g *simpleOpts) Items() (GeneratorOpts, error) {
-	return itemsGenOpts(g.name+".items", g.SimpleSchema.Items)
+	return itemsGenOpts(g.fieldName+".items", g.SimpleSchema.Items)
 }
 func (g *simpleOpts) Required() bool {
 	return g.required
 }
+func (g *simpleOpts) DefaultValue() (interface{}, bool) {
+	return g.SimpleSchema.Default, g.SimpleSchema.Default != nil
+}
+func (g *simpleOpts) ExampleValue() (interface{}, bool) {
+	return g.example, g.example != nil
+}
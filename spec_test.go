@@ -61,26 +61,15 @@ func testStubsGenerator(t *testing.T, haltOnErrors bool, continueOnErrors bool)
 					if assert.Truef(t, res.IsValid(), "Expected this spec to be valid: %v", res.Errors) {
 						// Now walks the spec to generate stubs
 						analyzer := analysis.New(doc.Spec())
+						gen := GeneratorForDocument(doc)
 						for method, pathItem := range analyzer.Operations() {
 							if pathItem != nil { // Safeguard
 								for path := range pathItem {
-									params := []spec.Parameter{}
-									// parameters
-									for _, ppr := range analyzer.ParamsFor(method, path) {
-										// Expand params (TODO: update with current expand method)
-										pr := ppr
-										sw := doc.Spec()
-										for pr.Ref.String() != "" {
-											obj, _, _ := pr.Ref.GetPointer().Get(sw)
-											pr = obj.(spec.Parameter)
-										}
-										params = append(params, pr)
-									}
 									// TODO: should sort params to get a repeatable random generation
-									for _, param := range params {
+									for _, param := range analyzer.ParamsFor(method, path) {
 										//Debug = true
 										t.Logf("Parameter: [name: %s, in:%s]", param.Name, param.In)
-										fixture, err := generateStubsForParam(t, path, method, param)
+										fixture, err := generateStubsForParam(t, gen, path, method, param)
 										assert.NoError(t, err)
 										t.Logf("Stub: %v", fixture)
 									}
@@ -119,8 +108,7 @@ type GeneratedFixture struct {
 	ParamsAsJSON json.RawMessage
 }
 
-func generateStubsForParam(t *testing.T, path, method string, param spec.Parameter) (*FixtureParam, error) {
-	gen := Generator{"en"}
+func generateStubsForParam(t *testing.T, gen *Generator, path, method string, param spec.Parameter) (*FixtureParam, error) {
 	//strings.Join([]string{method, path}, ":")
 	result, err := gen.Generate("", &param)
 	assert.NoError(t, err)
@@ -149,3 +137,64 @@ func Test_SingleFixture(t *testing.T) {
 		t.Logf("Load error: %v", err)
 	}
 }
+
+// BenchmarkGenerator_Spec measures the cost of generating a value for every parameter and definition of the fixture specs,
+// with a generator reused across values or a new one for each value
+func BenchmarkGenerator_Spec(b *testing.B) {
+	type descriptor struct {
+		doc        *loads.Document
+		key        string
+		descriptor interface{}
+	}
+	descriptors := make([]descriptor, 0, 100)
+	err := filepath.Walk(filepath.Join("fixtures", "specs"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		doc, err := loads.Spec(path)
+		if err != nil {
+			return err
+		}
+		analyzer := analysis.New(doc.Spec())
+		for method, pathItem := range analyzer.Operations() {
+			for path := range pathItem {
+				for _, param := range analyzer.ParamsFor(method, path) {
+					param := param
+					descriptors = append(descriptors, descriptor{doc: doc, key: param.Name, descriptor: &param})
+				}
+			}
+		}
+		for name := range doc.Spec().Definitions {
+			descriptors = append(descriptors, descriptor{doc: doc, key: name, descriptor: spec.RefSchema("#/definitions/" + name)})
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	generators := make(map[*loads.Document]*Generator)
+	for _, d := range descriptors {
+		generators[d.doc] = GeneratorForDocument(d.doc)
+	}
+
+	for _, bench := range []struct {
+		name      string
+		generator func(*loads.Document) *Generator
+	}{
+		{name: "reused", generator: func(doc *loads.Document) *Generator { return generators[doc] }},
+		{name: "fresh", generator: GeneratorForDocument},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, d := range descriptors {
+					if _, err := bench.generator(d.doc).Generate(d.key, d.descriptor); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(descriptors)), "ns/value")
+		})
+	}
+}
@@ -0,0 +1,459 @@
+package stubs
+
+import (
+	"math"
+	"reflect"
+)
+
+// tiltableModes lists the invalid modes supported by tilting, in the order tilts are applied.
+//
+// Bounds are tilted before multipleOf, so the value may be shifted away from the violated bound.
+var tiltableModes = []StubMode{
+	InvalidMaximum,
+	InvalidMinimum,
+	InvalidMultipleOf,
+	InvalidMaxLength,
+	InvalidMinLength,
+	InvalidPattern,
+	InvalidEnum,
+	InvalidMaxItems,
+	InvalidMinItems,
+	InvalidUniqueItems,
+}
+
+// tiltablePatternRunes are the replacement characters tried to break a pattern
+var tiltablePatternRunes = []rune{' ', '!', '0', 'a', 'A', '_', '~', '.'}
+
+// tilter alters a valid value just enough to violate a single validation.
+//
+// It returns ErrNoInvalid when the value cannot be tilted.
+type tilter func(value interface{}, opts GeneratorOpts) (interface{}, error)
+
+func (g *generators) tilterFor(mode StubMode) tilter {
+	switch mode {
+	case InvalidMaximum:
+		return g.tiltMaximum
+	case InvalidMinimum:
+		return g.tiltMinimum
+	case InvalidMultipleOf:
+		return g.tiltMultipleOf
+	case InvalidMaxLength:
+		return g.tiltMaxLength
+	case InvalidMinLength:
+		return g.tiltMinLength
+	case InvalidPattern:
+		return g.tiltPattern
+	case InvalidEnum:
+		return g.tiltEnumValue
+	case InvalidMaxItems:
+		return g.tiltMaxItems
+	case InvalidMinItems:
+		return g.tiltMinItems
+	case InvalidUniqueItems:
+		return g.tiltUniqueItems
+	default:
+		return nil
+	}
+}
+
+// validationModes yields the invalid modes which make sense for the validations carried by some generation options
+func validationModes(opts GeneratorOpts) StubMode {
+	var modes StubMode
+	if _, found := opts.Enum(); found {
+		modes |= InvalidEnum
+	}
+	switch opts.Type() {
+	case "integer", "number":
+		if _, _, found := opts.Maximum(); found {
+			modes |= InvalidMaximum
+		}
+		if _, _, found := opts.Minimum(); found {
+			modes |= InvalidMinimum
+		}
+		if multipleOf, found := opts.MultipleOf(); found && (opts.Type() == "number" || !isMultipleOf(1, multipleOf)) {
+			modes |= InvalidMultipleOf
+		}
+	case "string":
+		if _, found := opts.MaxLength(); found {
+			modes |= InvalidMaxLength
+		}
+		if minLength, found := opts.MinLength(); found && minLength > 0 {
+			modes |= InvalidMinLength
+		}
+		if _, found := opts.Pattern(); found {
+			modes |= InvalidPattern
+		}
+	case "array":
+		if _, found := opts.MaxItems(); found {
+			modes |= InvalidMaxItems
+		}
+		if minItems, found := opts.MinItems(); found && minItems > 0 {
+			modes |= InvalidMinItems
+		}
+		if opts.UniqueItems() {
+			modes |= InvalidUniqueItems
+		}
+	case "object":
+		if obj, ok := opts.(objectGeneratorOpts); ok {
+			props, _ := obj.Properties()
+			for _, prop := range props {
+				if prop.Required() {
+					modes |= InvalidRequired
+					break
+				}
+			}
+		}
+	}
+	return modes
+}
+
+// pickMode selects at random one of the flags set in a mode
+func (g *generators) pickMode(modes StubMode) StubMode {
+	flags := make([]StubMode, 0, 12)
+	for flag := Invalid; flag <= InvalidEnum; flag <<= 1 {
+		if modes.Has(flag) {
+			flags = append(flags, flag)
+		}
+	}
+	if len(flags) == 0 {
+		return Valid
+	}
+	return flags[g.entropy.IntN(len(flags))]
+}
+
+// tilt alters a valid value just enough so that it fails the validations selected by the tilted mode
+func (g *generators) tilt(value interface{}, opts GeneratorOpts, tilted StubMode) (interface{}, error) {
+	for _, mode := range tiltableModes {
+		if !tilted.Has(mode) {
+			continue
+		}
+		res, err := g.tilterFor(mode)(value, opts)
+		if err != nil {
+			debugLog("could not tilt value %v for %q with mode %d", value, opts.FieldName(), mode)
+			return nil, err
+		}
+		value = res
+	}
+	return value, nil
+}
+
+// tiltMaximum yields the lowest invalid value above maximum.
+//
+// Unless multipleOf is also tilted, the tilted value remains a multiple.
+func (g *generators) tiltMaximum(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	maximum, exclusive, defined := opts.Maximum()
+	if !defined {
+		return nil, ErrNoInvalid
+	}
+	return g.tiltBound(value, opts, maximum, exclusive, 1)
+}
+
+// tiltMinimum yields the highest invalid value below minimum.
+//
+// Unless multipleOf is also tilted, the tilted value remains a multiple.
+func (g *generators) tiltMinimum(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	minimum, exclusive, defined := opts.Minimum()
+	if !defined {
+		return nil, ErrNoInvalid
+	}
+	return g.tiltBound(value, opts, minimum, exclusive, -1)
+}
+
+// tiltBound yields the first invalid value beyond a bound, in the direction given by sign
+func (g *generators) tiltBound(value interface{}, opts GeneratorOpts, bound float64, exclusive bool, sign float64) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	isInteger := isIntegerKind(v.Kind())
+
+	var step float64
+	if multipleOf, defined := opts.MultipleOf(); defined && multipleOf > 0 && !opts.Mode().Has(InvalidMultipleOf) {
+		step = multipleOf
+	}
+	if isInteger {
+		s, ok := integerStep(step)
+		if !ok {
+			return nil, ErrNoInvalid
+		}
+		step = float64(s)
+	}
+
+	var tilted float64
+	switch {
+	case step > 0 && exclusive && isMultipleOf(bound, step):
+		tilted = bound
+	case step > 0:
+		// the first multiple of step strictly beyond bound
+		k := math.Floor(sign*bound/step) + 1
+		tilted = sign * k * step
+	case exclusive:
+		tilted = bound
+	case v.Kind() == reflect.Float32:
+		tilted = float64(math.Nextafter32(float32(bound), float32(sign*math.Inf(1))))
+	default:
+		tilted = math.Nextafter(bound, sign*math.Inf(1))
+	}
+
+	res, ok := setNumber(v, tilted)
+	if !ok {
+		return nil, ErrNoInvalid
+	}
+	return res, nil
+}
+
+// tiltMultipleOf shifts a number by the smallest amount which breaks multipleOf.
+//
+// The value is shifted in the direction which does not change its validity against minimum and maximum.
+func (g *generators) tiltMultipleOf(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	multipleOf, defined := opts.MultipleOf()
+	if !defined || multipleOf <= 0 {
+		return nil, ErrNoInvalid
+	}
+	v := reflect.ValueOf(value)
+	f, ok := numberValue(v)
+	if !ok {
+		return nil, ErrNoInvalid
+	}
+
+	delta := multipleOf / 2
+	if isIntegerKind(v.Kind()) {
+		step, ok := integerStep(multipleOf)
+		if !ok || step == 1 {
+			return nil, ErrNoInvalid
+		}
+		delta = 1
+	}
+
+	inBounds := numberInBounds(f, opts)
+	for _, candidate := range []float64{f + delta, f - delta} {
+		if numberInBounds(candidate, opts) != inBounds {
+			continue
+		}
+		if res, ok := setNumber(v, candidate); ok {
+			return res, nil
+		}
+	}
+	return nil, ErrNoInvalid
+}
+
+// tiltMaxLength extends a string up to one character beyond maxLength, by repeating its last character
+func (g *generators) tiltMaxLength(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	str, ok := value.(string)
+	maxLength, defined := opts.MaxLength()
+	if !ok || !defined {
+		return nil, ErrNoInvalid
+	}
+	runes := []rune(str)
+	if len(runes) > int(maxLength) {
+		runes = runes[:maxLength]
+	}
+	padding := 'a'
+	if len(runes) > 0 {
+		padding = runes[len(runes)-1]
+	}
+	for len(runes) <= int(maxLength) {
+		runes = append(runes, padding)
+	}
+	return string(runes), nil
+}
+
+// tiltMinLength truncates a string to one character below minLength
+func (g *generators) tiltMinLength(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	str, ok := value.(string)
+	minLength, defined := opts.MinLength()
+	if !ok || !defined || minLength <= 0 {
+		return nil, ErrNoInvalid
+	}
+	runes := []rune(str)
+	if len(runes) >= int(minLength) {
+		runes = runes[:minLength-1]
+	}
+	return string(runes), nil
+}
+
+// tiltPattern changes the smallest number of characters in a string so that the pattern no more matches.
+//
+// It first attempts to replace a single character, then to append one, and eventually falls back to the empty string.
+//
+// Patterns with lookarounds or backreferences cannot be checked exactly with Go regexp: these are never tilted.
+func (g *generators) tiltPattern(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	str, ok := value.(string)
+	pattern, defined := opts.Pattern()
+	if !ok || !defined {
+		return nil, ErrNoInvalid
+	}
+	rex, exact, err := compileExactPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if !exact {
+		// a tilted value could still match the original pattern
+		debugLog("pattern %q is approximated and cannot be tilted", pattern)
+		return nil, ErrNoInvalid
+	}
+
+	runes := []rune(str)
+	// start at a random position, so the tilted character varies
+	start := 0
+	if len(runes) > 0 {
+		start = g.entropy.IntN(len(runes))
+	}
+	for i := range runes {
+		pos := (start + i) % len(runes)
+		original := runes[pos]
+		for _, r := range tiltablePatternRunes {
+			if r == original {
+				continue
+			}
+			runes[pos] = r
+			if tilted := string(runes); !rex.MatchString(tilted) {
+				return tilted, nil
+			}
+		}
+		runes[pos] = original
+	}
+	for _, r := range tiltablePatternRunes {
+		if tilted := str + string(r); !rex.MatchString(tilted) {
+			return tilted, nil
+		}
+	}
+	if !rex.MatchString("") {
+		return "", nil
+	}
+	return nil, ErrNoInvalid
+}
+
+// tiltEnumValue alters a value which belongs to an enum, so it is no more a member
+func (g *generators) tiltEnumValue(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	enum, defined := opts.Enum()
+	if !defined {
+		return nil, ErrNoInvalid
+	}
+	values := make([]interface{}, 0, len(enum))
+	for _, member := range enum {
+		v, err := g.typedValue(member, opts.Type(), opts.Format())
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return g.tiltEnum(value, values)
+}
+
+// tiltMaxItems extends an array up to one item beyond maxItems, by repeating its items.
+//
+// This is not possible when items must be unique.
+func (g *generators) tiltMaxItems(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	items, ok := value.([]interface{})
+	maxItems, defined := opts.MaxItems()
+	if !ok || !defined || len(items) == 0 || opts.UniqueItems() {
+		return nil, ErrNoInvalid
+	}
+	res := make([]interface{}, 0, maxItems+1)
+	for i := 0; len(res) <= int(maxItems); i++ {
+		res = append(res, items[i%len(items)])
+	}
+	return res, nil
+}
+
+// tiltMinItems truncates an array to one item below minItems
+func (g *generators) tiltMinItems(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	items, ok := value.([]interface{})
+	minItems, defined := opts.MinItems()
+	if !ok || !defined || minItems <= 0 {
+		return nil, ErrNoInvalid
+	}
+	if len(items) >= int(minItems) {
+		items = items[:minItems-1]
+	}
+	return items, nil
+}
+
+// tiltUniqueItems duplicates one item in an array.
+//
+// The array is extended with the duplicate, unless this would exceed maxItems.
+func (g *generators) tiltUniqueItems(value interface{}, opts GeneratorOpts) (interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok || !opts.UniqueItems() || len(items) == 0 {
+		return nil, ErrNoInvalid
+	}
+	duplicate := items[g.entropy.IntN(len(items))]
+	if maxItems, defined := opts.MaxItems(); !defined || len(items) < int(maxItems) {
+		return append(items, duplicate), nil
+	}
+	if len(items) < 2 {
+		return nil, ErrNoInvalid
+	}
+	res := make([]interface{}, len(items))
+	copy(res, items)
+	for i := range res {
+		if !reflect.DeepEqual(res[i], duplicate) {
+			res[i] = duplicate
+			break
+		}
+	}
+	return res, nil
+}
+
+// numberInBounds tells if a number abides by the minimum and maximum validations
+func numberInBounds(f float64, opts GeneratorOpts) bool {
+	if maximum, exclusive, defined := opts.Maximum(); defined && (f > maximum || (exclusive && f == maximum)) {
+		return false
+	}
+	if minimum, exclusive, defined := opts.Minimum(); defined && (f < minimum || (exclusive && f == minimum)) {
+		return false
+	}
+	return true
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// numberValue converts any numeric value to a float64
+func numberValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// setNumber converts a float64 to the numeric type of some value.
+//
+// It returns false when the float cannot be represented exactly by this type.
+func setNumber(like reflect.Value, f float64) (interface{}, bool) {
+	if !like.IsValid() {
+		return nil, false
+	}
+	res := reflect.New(like.Type()).Elem()
+	switch like.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || res.OverflowInt(int64(f)) {
+			return nil, false
+		}
+		res.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || res.OverflowUint(uint64(f)) {
+			return nil, false
+		}
+		res.SetUint(uint64(f))
+	case reflect.Float32, reflect.Float64:
+		if math.IsInf(f, 0) || math.IsNaN(f) || res.OverflowFloat(f) {
+			return nil, false
+		}
+		res.SetFloat(f)
+	default:
+		return nil, false
+	}
+	return res.Interface(), true
+}
@@ -0,0 +1,147 @@
+package stubs
+
+import (
+	"fmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+)
+
+// maxVerifyRetries is the number of attempts made to generate a value which passes verification
+const maxVerifyRetries = 10
+
+// violationCodes maps the codes of validation errors to the mode producing such errors
+var violationCodes = map[int32]StubMode{
+	errors.RequiredFailCode:   InvalidRequired,
+	errors.MaxFailCode:        InvalidMaximum,
+	errors.MinFailCode:        InvalidMinimum,
+	errors.TooLongFailCode:    InvalidMaxLength,
+	errors.TooShortFailCode:   InvalidMinLength,
+	errors.PatternFailCode:    InvalidPattern,
+	errors.MaxItemsFailCode:   InvalidMaxItems,
+	errors.MinItemsFailCode:   InvalidMinItems,
+	errors.UniqueFailCode:     InvalidUniqueItems,
+	errors.MultipleOfFailCode: InvalidMultipleOf,
+	errors.EnumFailCode:       InvalidEnum,
+}
+
+// verifier checks a value against a descriptor, and yields the validation errors
+type verifier func(interface{}) []error
+
+// verifierFor builds a verifier for a descriptor, relying on go-openapi/validate
+func (s *Generator) verifierFor(key string, descriptor interface{}) (verifier, error) {
+	switch desc := descriptor.(type) {
+	case *spec.Parameter:
+		param, err := s.resolveParameter(desc)
+		if err != nil {
+			return nil, err
+		}
+		if param.Schema != nil {
+			return s.verifierFor(key, param.Schema)
+		}
+		return func(value interface{}) []error {
+			return validate.NewParamValidator(param, strfmt.Default).Validate(value).Errors
+		}, nil
+	case *spec.Header:
+		return func(value interface{}) []error {
+			return validate.NewHeaderValidator(key, desc, strfmt.Default).Validate(value).Errors
+		}, nil
+	case *spec.Schema:
+		var root interface{} = desc
+		if s.Spec != nil {
+			root = s.Spec
+		}
+		return func(value interface{}) []error {
+			return validate.NewSchemaValidator(desc, root, "", strfmt.Default).Validate(value).Errors
+		}, nil
+	case *spec.Response:
+		response, err := s.resolveResponse(desc)
+		if err != nil {
+			return nil, err
+		}
+		return s.verifierFor(key, response.Schema)
+	case spec.Parameter:
+		return s.verifierFor(key, &desc)
+	case spec.Header:
+		return s.verifierFor(key, &desc)
+	case spec.Schema:
+		return s.verifierFor(key, &desc)
+	case spec.Response:
+		return s.verifierFor(key, &desc)
+	default:
+		return nil, fmt.Errorf("%T is unsupported for Generator", descriptor)
+	}
+}
+
+// verified generates a value and, in Verify mode, checks it against the descriptor.
+//
+// When expected is Valid, the value must pass all validations. Otherwise, the value must fail at least the
+// validations flagged in expected: the generic Invalid flag stands for any validation.
+//
+// Values which fail verification are generated again, up to maxVerifyRetries times. The returned error then
+// wraps ErrNoValid or ErrNoInvalid, with the details of the last verification.
+func (s *Generator) verified(generator *generators, key string, descriptor interface{}, gopts GeneratorOpts, expected StubMode) (interface{}, error) {
+	if !s.Verify {
+		return generator.generate(gopts)
+	}
+	verify, err := s.verifierFor(key, descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	var details string
+	for i := 0; i < maxVerifyRetries; i++ {
+		value, err := generator.generate(gopts)
+		if err != nil {
+			return nil, err
+		}
+		errs := verify(value)
+		violations := violationsFrom(errs)
+		switch {
+		case expected == Valid && len(errs) == 0:
+			return value, nil
+		case expected != Valid && len(errs) > 0 && violations&expected == expected&^Invalid:
+			return value, nil
+		}
+		details = fmt.Sprintf("expected violations %d, got %d: %v", expected, violations, errs)
+		debugLog("verification failed for %q: %s", key, details)
+	}
+	if expected == Valid {
+		return nil, fmt.Errorf("%w: %s", ErrNoValid, details)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoInvalid, details)
+}
+
+// violationsFrom yields the modes corresponding to some validation errors. Errors without a corresponding mode
+// are reported with the generic Invalid flag.
+func violationsFrom(errs []error) StubMode {
+	var violations StubMode
+	for _, err := range errs {
+		switch e := err.(type) {
+		case *errors.CompositeError:
+			violations |= violationsFrom(e.Errors)
+		case *errors.Validation:
+			if mode, ok := violationCodes[e.Code()]; ok {
+				violations |= mode
+			} else {
+				violations |= Invalid
+			}
+		default:
+			violations |= Invalid
+		}
+	}
+	return violations
+}
+
+// declaredModes yields the invalid modes declared by generation options and their nested options, by path
+func declaredModes(gopts GeneratorOpts) map[string]StubMode {
+	modes := make(map[string]StubMode)
+	walkOpts(gopts, func(path, _ string, opts GeneratorOpts) {
+		if mode := opts.Mode(); mode != Valid {
+			modes[path] = mode
+		}
+	})
+	return modes
+}